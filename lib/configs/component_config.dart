@@ -8,6 +8,9 @@ class ComponentConfig {
   final ActionConfig? action;
   final List<ComponentConfig>? children;
 
+  /// Opaque token to echo back in impression/tap analytics events
+  final String? trackingToken;
+
   ComponentConfig({
     required this.id,
     required this.type,
@@ -15,6 +18,7 @@ class ComponentConfig {
     this.style,
     this.action,
     this.children,
+    this.trackingToken,
   });
 
   factory ComponentConfig.fromJson(Map<String, dynamic> json) {
@@ -29,6 +33,7 @@ class ComponentConfig {
       children: (json['children'] as List?)
           ?.map((c) => ComponentConfig.fromJson(c))
           .toList(),
+      trackingToken: json['tracking_token'],
     );
   }
 }
\ No newline at end of file
@@ -1,3 +1,5 @@
+import 'dart:async';
+
 import 'package:flutter/material.dart';
 import 'package:flutter_bloc/flutter_bloc.dart';
 import '../bloc/ui_config_bloc.dart';
@@ -553,8 +555,9 @@ class SduiWidgetBuilder {
             ),
           ),
           const SizedBox(width: 8),
-          Text(
-            endTime,
+          _CountdownText(
+            endTime: endTime,
+            serverTime: component.props['server_time'],
             style: TextStyle(
               color: Colors.white,
               fontSize: (component.style?['fontSize'] ?? 18.0).toDouble(),
@@ -1275,4 +1278,76 @@ class SduiWidgetBuilder {
         return Icons.circle;
     }
   }
-}
\ No newline at end of file
+}
+
+/// Ticks down to an RFC 3339 end time sent by the server, offset by the
+/// difference between the server's clock and ours. Anything that doesn't
+/// parse as a timestamp is shown as-is.
+class _CountdownText extends StatefulWidget {
+  final String endTime;
+  final String? serverTime;
+  final TextStyle style;
+
+  const _CountdownText({
+    required this.endTime,
+    required this.serverTime,
+    required this.style,
+  });
+
+  @override
+  State<_CountdownText> createState() => _CountdownTextState();
+}
+
+class _CountdownTextState extends State<_CountdownText> {
+  DateTime? _end;
+  Duration _skew = Duration.zero;
+  Timer? _timer;
+
+  @override
+  void initState() {
+    super.initState();
+    _parse();
+  }
+
+  @override
+  void didUpdateWidget(_CountdownText oldWidget) {
+    super.didUpdateWidget(oldWidget);
+    if (oldWidget.endTime != widget.endTime ||
+        oldWidget.serverTime != widget.serverTime) {
+      _parse();
+    }
+  }
+
+  void _parse() {
+    _timer?.cancel();
+    _end = DateTime.tryParse(widget.endTime);
+    final serverNow = DateTime.tryParse(widget.serverTime ?? '');
+    _skew = serverNow != null ? serverNow.difference(DateTime.now()) : Duration.zero;
+    if (_end != null) {
+      _timer = Timer.periodic(const Duration(seconds: 1), (_) => setState(() {}));
+    }
+  }
+
+  @override
+  void dispose() {
+    _timer?.cancel();
+    super.dispose();
+  }
+
+  @override
+  Widget build(BuildContext context) {
+    final end = _end;
+    if (end == null) {
+      return Text(widget.endTime, style: widget.style);
+    }
+
+    var remaining = end.difference(DateTime.now().add(_skew));
+    if (remaining.isNegative) {
+      remaining = Duration.zero;
+      _timer?.cancel();
+    }
+    final minutes = (remaining.inMinutes % 60).toString().padLeft(2, '0');
+    final seconds = (remaining.inSeconds % 60).toString().padLeft(2, '0');
+    return Text('${remaining.inHours}:$minutes:$seconds', style: widget.style);
+  }
+}
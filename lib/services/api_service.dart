@@ -96,6 +96,28 @@ class ApiService {
     }
   }
 
+  /// Send queued analytics events in a single request.
+  /// Returns the server's per-event results (accepted / rejected / dropped).
+  Future<List<dynamic>> trackEvents(List<Map<String, dynamic>> events) async {
+    if (events.isEmpty) return [];
+
+    try {
+      final response = await _client.post(
+        Uri.parse('$baseUrl/api/analytics'),
+        headers: {'Content-Type': 'application/json'},
+        body: jsonEncode(events),
+      );
+
+      if (response.statusCode == 200) {
+        return jsonDecode(response.body)['results'] as List<dynamic>;
+      }
+      throw Exception('Failed to submit events: ${response.statusCode}');
+    } catch (e) {
+      print('Error tracking events: $e');
+      rethrow;
+    }
+  }
+
   void dispose() {
     _client.close();
   }
@@ -0,0 +1,441 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ==================== PREFERENCES ====================
+
+const (
+	largeTextScale        = 1.3
+	contrastNormalText    = 4.5 // WCAG AA
+	contrastLargeText     = 3.0 // WCAG AA, text 24px and up
+	contrastHighContrast  = 7.0 // WCAG AAA, what high contrast mode aims for
+	largeTextMinFontSize  = 24.0
+	defaultHeaderFontSize = 24.0
+)
+
+// AccessibilityPrefs are the client's accessibility settings, sent as
+// "X-Accessibility: high_contrast, large_text" or ?a11y=reduced_motion
+type AccessibilityPrefs struct {
+	HighContrast  bool `json:"high_contrast"`
+	LargeText     bool `json:"large_text"`
+	ReducedMotion bool `json:"reduced_motion"`
+}
+
+func accessibilityFromRequest(r *http.Request) AccessibilityPrefs {
+	raw := r.Header.Get("X-Accessibility")
+	if raw == "" {
+		raw = r.URL.Query().Get("a11y")
+	}
+
+	var prefs AccessibilityPrefs
+	for _, pref := range strings.Split(raw, ",") {
+		switch strings.TrimSpace(strings.ToLower(pref)) {
+		case "high_contrast":
+			prefs.HighContrast = true
+		case "large_text":
+			prefs.LargeText = true
+		case "reduced_motion":
+			prefs.ReducedMotion = true
+		}
+	}
+	return prefs
+}
+
+func (p AccessibilityPrefs) enabled() []string {
+	var names []string
+	if p.HighContrast {
+		names = append(names, "high_contrast")
+	}
+	if p.LargeText {
+		names = append(names, "large_text")
+	}
+	if p.ReducedMotion {
+		names = append(names, "reduced_motion")
+	}
+	return names
+}
+
+// ==================== ADJUSTMENTS ====================
+
+// applyAccessibility adjusts a config with resolved theme tokens to the
+// user's accessibility preferences
+func applyAccessibility(config map[string]interface{}, prefs AccessibilityPrefs) {
+	enabled := prefs.enabled()
+	if len(enabled) == 0 {
+		return
+	}
+	theme, _ := config["theme"].(map[string]interface{})
+	components, _ := config["components"].([]interface{})
+
+	if prefs.HighContrast && theme != nil {
+		raiseThemeContrast(theme)
+		for _, violation := range checkContrast(components, theme, contrastHighContrast) {
+			violation.fix()
+		}
+	}
+	if prefs.LargeText {
+		if theme != nil {
+			if sizes, ok := theme["font_sizes"].(map[string]float64); ok {
+				for name, size := range sizes {
+					sizes[name] = math.Round(size * largeTextScale)
+				}
+			}
+			theme["text_scale"] = largeTextScale
+		}
+		scaleText(components)
+	}
+	if prefs.ReducedMotion {
+		if theme != nil {
+			theme["reduced_motion"] = true
+		}
+		disableAnimations(components)
+	}
+
+	if metadata, ok := config["metadata"].(map[string]interface{}); ok {
+		metadata["accessibility"] = enabled
+	}
+}
+
+// raiseThemeContrast pushes text colors to pure black or white
+func raiseThemeContrast(theme map[string]interface{}) {
+	text := "#000000"
+	if dark, _ := theme["is_dark_mode"].(bool); dark {
+		text = "#FFFFFF"
+	}
+	if colors, ok := theme["colors"].(map[string]interface{}); ok {
+		colors["text"] = text
+		colors["text_secondary"] = text
+	}
+	theme["high_contrast"] = true
+}
+
+// textSizeKeys are the style keys holding font sizes
+var textSizeKeys = []string{"fontSize", "titleSize", "subtitleSize", "priceSize", "iconSize"}
+
+func scaleText(components []interface{}) {
+	for _, c := range components {
+		component, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if style, ok := component["style"].(map[string]interface{}); ok {
+			for _, key := range textSizeKeys {
+				if size, ok := style[key].(float64); ok {
+					style[key] = math.Round(size * largeTextScale)
+				}
+			}
+		}
+		if children, ok := component["children"].([]interface{}); ok {
+			scaleText(children)
+		}
+	}
+}
+
+// disableAnimations stops banner entrances and ticking countdowns
+func disableAnimations(components []interface{}) {
+	for _, c := range components {
+		component, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch component["type"] {
+		case "animated_banner":
+			mergeInto(component, "props", map[string]interface{}{"duration": 0, "animate": false})
+		case "countdown_timer":
+			mergeInto(component, "props", map[string]interface{}{"animate": false})
+		}
+		if children, ok := component["children"].([]interface{}); ok {
+			disableAnimations(children)
+		}
+	}
+}
+
+// ==================== CONTRAST VALIDATION ====================
+
+// ContrastViolation is a text/background pair below the required ratio
+type ContrastViolation struct {
+	Screen      string  `json:"screen,omitempty"`
+	Mode        string  `json:"mode,omitempty"`
+	ComponentID string  `json:"component_id"`
+	Property    string  `json:"property"`
+	Foreground  string  `json:"foreground"`
+	Background  string  `json:"background"`
+	Ratio       float64 `json:"ratio"`
+	Required    float64 `json:"required"`
+
+	// fix rewrites the offending color so the pair meets the ratio
+	fix func()
+}
+
+// contrastRatio computes the WCAG 2 contrast ratio of two "#RRGGBB" colors
+func contrastRatio(fg, bg string) (float64, error) {
+	l1, err := relativeLuminance(fg)
+	if err != nil {
+		return 0, err
+	}
+	l2, err := relativeLuminance(bg)
+	if err != nil {
+		return 0, err
+	}
+	if l1 < l2 {
+		l1, l2 = l2, l1
+	}
+	return (l1 + 0.05) / (l2 + 0.05), nil
+}
+
+func relativeLuminance(color string) (float64, error) {
+	r, g, b, err := parseHexColor(color)
+	if err != nil {
+		return 0, err
+	}
+	channel := func(v uint8) float64 {
+		c := float64(v) / 255
+		if c <= 0.03928 {
+			return c / 12.92
+		}
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(r) + 0.7152*channel(g) + 0.0722*channel(b), nil
+}
+
+// parseHexColor reads "#RRGGBB" or "#AARRGGBB" (alpha ignored)
+func parseHexColor(color string) (r, g, b uint8, err error) {
+	hex := strings.TrimPrefix(color, "#")
+	if len(hex) == 8 {
+		hex = hex[2:]
+	}
+	if len(hex) != 6 {
+		return 0, 0, 0, fmt.Errorf("invalid color %q", color)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid color %q", color)
+	}
+	return uint8(v >> 16), uint8(v >> 8), uint8(v), nil
+}
+
+// bestTextColor picks black or white, whichever reads better on bg
+func bestTextColor(bg string) string {
+	onWhite, _ := contrastRatio("#FFFFFF", bg)
+	onBlack, _ := contrastRatio("#000000", bg)
+	if onWhite >= onBlack {
+		return "#FFFFFF"
+	}
+	return "#000000"
+}
+
+// darkenUntil darkens bg in steps until white text reaches the ratio
+func darkenUntil(bg string, required float64) string {
+	r, g, b, err := parseHexColor(bg)
+	if err != nil {
+		return bg
+	}
+	for i := 0; i < 40; i++ {
+		candidate := fmt.Sprintf("#%02X%02X%02X", r, g, b)
+		if ratio, _ := contrastRatio("#FFFFFF", candidate); ratio >= required {
+			return candidate
+		}
+		r, g, b = uint8(float64(r)*0.9), uint8(float64(g)*0.9), uint8(float64(b)*0.9)
+	}
+	return "#000000"
+}
+
+// checkContrast finds text/background pairs under the WCAG AA ratio for
+// their size, or under minimum when that's stricter
+func checkContrast(components []interface{}, theme map[string]interface{}, minimum float64) []ContrastViolation {
+	background, _ := theme["background_color"].(string)
+	return checkContrastOn(components, background, theme, minimum)
+}
+
+// checkContrastOn checks components drawn over the given background
+func checkContrastOn(components []interface{}, background string, theme map[string]interface{}, minimum float64) []ContrastViolation {
+	var violations []ContrastViolation
+	for _, c := range components {
+		component, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		violations = append(violations, componentContrast(component, background, theme, minimum)...)
+		if children, ok := component["children"].([]interface{}); ok {
+			inner := background
+			if style, ok := component["style"].(map[string]interface{}); ok {
+				if bg, ok := style["backgroundColor"].(string); ok {
+					inner = bg
+				}
+			}
+			violations = append(violations, checkContrastOn(children, inner, theme, minimum)...)
+		}
+	}
+	return violations
+}
+
+func componentContrast(component map[string]interface{}, parentBackground string, theme map[string]interface{}, minimum float64) []ContrastViolation {
+	style, _ := component["style"].(map[string]interface{})
+	id, _ := component["id"].(string)
+	colors, _ := theme["colors"].(map[string]interface{})
+	surface, _ := colors["surface"].(string)
+
+	required := func(fontSize float64) float64 {
+		ratio := contrastNormalText
+		if fontSize >= largeTextMinFontSize {
+			ratio = contrastLargeText
+		}
+		return math.Max(ratio, minimum)
+	}
+	fontSize, _ := style["fontSize"].(float64)
+
+	var violations []ContrastViolation
+	// text drawn in a style color over the component (or page) background
+	checkText := func(key, fallback, background string, size float64) {
+		fg, _ := style[key].(string)
+		if fg == "" {
+			fg = fallback
+		}
+		ratio, err := contrastRatio(fg, background)
+		if err != nil || ratio >= required(size) {
+			return
+		}
+		violations = append(violations, ContrastViolation{
+			ComponentID: id, Property: key, Foreground: fg, Background: background,
+			Ratio: math.Round(ratio*100) / 100, Required: required(size),
+			fix: func() {
+				// Unstyled components only get a style once a fix writes one
+				if style == nil {
+					style = map[string]interface{}{}
+					component["style"] = style
+				}
+				style[key] = bestTextColor(background)
+			},
+		})
+	}
+	// white text drawn over a colored fill the style controls
+	checkFill := func(key string, size float64) {
+		bg, _ := style[key].(string)
+		if bg == "" {
+			return
+		}
+		ratio, err := contrastRatio("#FFFFFF", bg)
+		if err != nil || ratio >= required(size) {
+			return
+		}
+		need := required(size)
+		violations = append(violations, ContrastViolation{
+			ComponentID: id, Property: key, Foreground: "#FFFFFF", Background: bg,
+			Ratio: math.Round(ratio*100) / 100, Required: need,
+			fix: func() {
+				style[key] = darkenUntil(bg, need)
+				delete(style, "gradient")
+			},
+		})
+	}
+
+	background := parentBackground
+	if bg, ok := style["backgroundColor"].(string); ok {
+		background = bg
+	}
+
+	switch component["type"] {
+	case "header":
+		if fontSize == 0 {
+			fontSize = defaultHeaderFontSize
+		}
+		checkText("color", "#000000", background, fontSize)
+		if _, ok := style["subtitleColor"]; ok {
+			size, _ := style["subtitleSize"].(float64)
+			checkText("subtitleColor", "#666666", background, size)
+		}
+	case "banner", "animated_banner", "countdown_timer", "promo_badge":
+		size, _ := style["titleSize"].(float64)
+		if size == 0 {
+			size = fontSize
+		}
+		checkFill("backgroundColor", size)
+		if gradient, ok := style["gradient"].(map[string]interface{}); ok {
+			for i, color := range gradientStops(gradient) {
+				if ratio, err := contrastRatio("#FFFFFF", color); err == nil && ratio < required(size) {
+					violations = append(violations, ContrastViolation{
+						ComponentID: id, Property: fmt.Sprintf("gradient.colors[%d]", i),
+						Foreground: "#FFFFFF", Background: color,
+						Ratio: math.Round(ratio*100) / 100, Required: required(size),
+						fix: func() { delete(style, "gradient") },
+					})
+				}
+			}
+		}
+	case "product_grid", "product_carousel":
+		if _, ok := style["priceColor"]; ok && surface != "" {
+			size, _ := style["priceSize"].(float64)
+			checkText("priceColor", "", surface, size)
+		}
+	case "button":
+		checkFill("backgroundColor", fontSize)
+	}
+	return violations
+}
+
+func gradientStops(gradient map[string]interface{}) []string {
+	switch stops := gradient["colors"].(type) {
+	case []string:
+		return stops
+	case []interface{}:
+		colors := make([]string, 0, len(stops))
+		for _, stop := range stops {
+			if color, ok := stop.(string); ok {
+				colors = append(colors, color)
+			}
+		}
+		return colors
+	}
+	return nil
+}
+
+// ==================== HANDLER ====================
+
+// handleContrastReport lints every screen in every mode against WCAG AA
+func handleContrastReport(w http.ResponseWriter, r *http.Request) {
+	screens := []struct {
+		name  string
+		build func(mode string) map[string]interface{}
+	}{
+		{"home", func(mode string) map[string]interface{} { return getHomeScreenConfig(mode, "") }},
+		{"product", func(mode string) map[string]interface{} { return getProductScreenConfig(mode, "prod_1") }},
+		{"cart", getCartScreenConfig},
+		{"search", getSearchScreenConfig},
+		{"favorites", getFavoritesScreenConfig},
+		{"profile", getProfileScreenConfig},
+	}
+	// A stable order lets operators diff one report against the next
+	modes := make([]string, 0, len(themeRegistry))
+	for mode := range themeRegistry {
+		modes = append(modes, mode)
+	}
+	sort.Strings(modes)
+
+	violations := []ContrastViolation{}
+	for _, screen := range screens {
+		for _, mode := range modes {
+			config := screen.build(mode)
+			resolveThemeTokens(config)
+			theme, _ := config["theme"].(map[string]interface{})
+			components, _ := config["components"].([]interface{})
+			for _, violation := range checkContrast(components, theme, 0) {
+				violation.Screen, violation.Mode = screen.name, mode
+				violations = append(violations, violation)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"violations": violations,
+		"count":      len(violations),
+	})
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// High contrast fixes only give a component a style when a fix writes one
+func TestHighContrastStyles(t *testing.T) {
+	config := map[string]interface{}{
+		"theme": map[string]interface{}{"background_color": "#FFFFFF", "colors": map[string]interface{}{}},
+		"components": []interface{}{
+			map[string]interface{}{"id": "plain", "type": "text"},
+			map[string]interface{}{"id": "readable", "type": "header"},
+			map[string]interface{}{"id": "dark-row", "type": "row", "style": map[string]interface{}{"backgroundColor": "#000000"}, "children": []interface{}{
+				map[string]interface{}{"id": "unreadable", "type": "header"},
+			}},
+		},
+	}
+	applyAccessibility(config, AccessibilityPrefs{HighContrast: true})
+
+	components := config["components"].([]interface{})
+	for _, id := range []string{"plain", "readable"} {
+		if style, ok := findComponent(components, id)["style"]; ok {
+			t.Errorf("%s gained style %v", id, style)
+		}
+	}
+	style, _ := findComponent(components, "unreadable")["style"].(map[string]interface{})
+	if style["color"] != "#FFFFFF" {
+		t.Errorf("unreadable style = %v, want white text", style)
+	}
+}
+
+func TestContrastReportOrder(t *testing.T) {
+	report := func() []byte {
+		rec := httptest.NewRecorder()
+		handleContrastReport(rec, httptest.NewRequest(http.MethodGet, "/api/admin/contrast", nil))
+		return rec.Body.Bytes()
+	}
+	first := report()
+	for i := 0; i < 5; i++ {
+		if !bytes.Equal(report(), first) {
+			t.Fatal("contrast report order changed between requests")
+		}
+	}
+}
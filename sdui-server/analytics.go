@@ -0,0 +1,410 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ==================== EVENTS ====================
+
+// knownEventTypes lists every event type the pipeline accepts
+var knownEventTypes = map[string]bool{
+	"screen_view":      true,
+	"impression":       true,
+	"tap":              true,
+	"product_view":     true,
+	"add_to_cart":      true,
+	"remove_from_cart": true,
+	"favorite":         true,
+	"unfavorite":       true,
+	"search":           true,
+	"checkout":         true,
+	"purchase":         true,
+}
+
+// serverEventTypes are recorded by the server itself (handleOrders logs
+// purchases at checkout), so client copies would be double-counted
+var serverEventTypes = map[string]bool{
+	"purchase": true,
+}
+
+// AnalyticsEvent is a validated client event enriched with server context
+type AnalyticsEvent struct {
+	ID              string                 `json:"id"`
+	Type            string                 `json:"event_type"`
+	Data            map[string]interface{} `json:"data,omitempty"`
+	ClientTimestamp string                 `json:"client_timestamp,omitempty"`
+	ServerTime      time.Time              `json:"server_time"`
+	Mode            string                 `json:"mode"`
+	UserID          string                 `json:"user_id,omitempty"`
+	Screen          string                 `json:"screen,omitempty"`
+	Attribution     *Attribution           `json:"attribution,omitempty"`
+	Variant         string                 `json:"variant,omitempty"` // "experiment:variant,..."
+}
+
+// rawEvent is the payload sent by ApiService.trackEvent
+type rawEvent struct {
+	EventType string                 `json:"event_type"`
+	Data      map[string]interface{} `json:"data"`
+	Timestamp string                 `json:"timestamp"`
+}
+
+func (raw rawEvent) validate() error {
+	if raw.EventType == "" {
+		return fmt.Errorf("event_type is required")
+	}
+	if !knownEventTypes[raw.EventType] {
+		return fmt.Errorf("unknown event_type %q", raw.EventType)
+	}
+	if serverEventTypes[raw.EventType] {
+		return fmt.Errorf("event_type %q is recorded by the server", raw.EventType)
+	}
+	return nil
+}
+
+// enrichEvent stamps a validated event with server time, mode and user
+func enrichEvent(raw rawEvent, r *http.Request) AnalyticsEvent {
+	event := AnalyticsEvent{
+		ID:              "evt_" + randomID(8),
+		Type:            raw.EventType,
+		Data:            raw.Data,
+		ClientTimestamp: raw.Timestamp,
+		ServerTime:      time.Now().UTC(),
+		Mode:            getCurrentMode(),
+		UserID:          currentUserID(r),
+	}
+	if screen, ok := raw.Data["screen"].(string); ok {
+		event.Screen = screen
+	}
+	attributeEvent(&event)
+
+	// Tag the event with the experiment variants the user saw on that screen
+	screen, mode := event.Screen, event.Mode
+	if a := event.Attribution; a != nil && a.Mode != "" {
+		mode = a.Mode
+	}
+	if screen != "" {
+		event.Variant = formatAssignments(experimentAssignments(screen, mode, event.UserID))
+	}
+	return event
+}
+
+// ==================== PIPELINE ====================
+
+// eventSink is an append-only destination for batches of events
+type eventSink interface {
+	Name() string
+	WriteBatch(events []AnalyticsEvent) error
+	Close() error
+}
+
+// analyticsPipeline buffers events in a bounded channel and writes them to
+// every sink in batches. Enqueue never blocks: when the buffer is full the
+// event is dropped and counted so request handling is never held up.
+type analyticsPipeline struct {
+	events        chan AnalyticsEvent
+	sinks         []eventSink
+	batchSize     int
+	flushInterval time.Duration
+
+	accepted atomic.Int64
+	dropped  atomic.Int64
+
+	// closed guards events: handlers still finishing during shutdown may
+	// Enqueue after Close, which must drop rather than send on a closed channel
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+func newAnalyticsPipeline(bufferSize, batchSize int, flushInterval time.Duration, sinks ...eventSink) *analyticsPipeline {
+	p := &analyticsPipeline{
+		events:        make(chan AnalyticsEvent, bufferSize),
+		sinks:         sinks,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		done:          make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// Enqueue hands an event to the writer goroutine, returning false when the
+// buffer is full
+func (p *analyticsPipeline) Enqueue(event AnalyticsEvent) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		p.dropped.Add(1)
+		return false
+	}
+	select {
+	case p.events <- event:
+		p.accepted.Add(1)
+		return true
+	default:
+		p.dropped.Add(1)
+		return false
+	}
+}
+
+func (p *analyticsPipeline) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]AnalyticsEvent, 0, p.batchSize)
+	for {
+		select {
+		case event, ok := <-p.events:
+			if !ok {
+				p.flush(batch)
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= p.batchSize {
+				p.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			p.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (p *analyticsPipeline) flush(batch []AnalyticsEvent) {
+	if len(batch) == 0 {
+		return
+	}
+	for _, sink := range p.sinks {
+		if err := sink.WriteBatch(batch); err != nil {
+			log.Printf("❌ Analytics sink %s failed to write %d events: %v", sink.Name(), len(batch), err)
+		}
+	}
+}
+
+// Close drains buffered events, flushes them and closes every sink
+func (p *analyticsPipeline) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.events)
+	p.mu.Unlock()
+
+	<-p.done
+	for _, sink := range p.sinks {
+		if err := sink.Close(); err != nil {
+			log.Printf("❌ Analytics sink %s failed to close: %v", sink.Name(), err)
+		}
+	}
+}
+
+// Stats reports the pipeline's counters and how full its buffer is
+func (p *analyticsPipeline) Stats() map[string]interface{} {
+	return map[string]interface{}{
+		"accepted": p.accepted.Load(),
+		"dropped":  p.dropped.Load(),
+		"buffered": len(p.events),
+		"capacity": cap(p.events),
+	}
+}
+
+var analytics *analyticsPipeline
+
+// initAnalytics wires the JSONL and SQLite sinks. Paths come from
+// SDUI_ANALYTICS_DIR and SDUI_ANALYTICS_DB.
+func initAnalytics() {
+	dir := envOr("SDUI_ANALYTICS_DIR", "data/analytics")
+	dbPath := envOr("SDUI_ANALYTICS_DB", "data/analytics.db")
+
+	var sinks []eventSink
+	if sink, err := newJSONLSink(dir, 10<<20); err != nil {
+		log.Printf("⚠️  JSONL analytics sink disabled: %v", err)
+	} else {
+		sinks = append(sinks, sink)
+	}
+	if sink, err := newSQLiteSink(dbPath); err != nil {
+		log.Printf("⚠️  SQLite analytics sink disabled: %v", err)
+	} else {
+		sinks = append(sinks, sink)
+		analyticsDB = sink.db
+	}
+
+	analytics = newAnalyticsPipeline(10_000, 500, 2*time.Second, sinks...)
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// ==================== HANDLER ====================
+
+// maxAnalyticsBody caps a single submission (after decompression)
+const maxAnalyticsBody = 5 << 20
+
+// eventResult reports what happened to one event in a submission
+type eventResult struct {
+	Index  int    `json:"index"`
+	Status string `json:"status"` // "accepted", "rejected" or "dropped"
+	ID     string `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Analytics endpoint: accepts a single event object, a JSON array of
+// events, or NDJSON (optionally gzip-compressed) so clients can flush
+// queued events in one request
+func handleAnalytics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := readAnalyticsBody(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	messages, single, err := splitAnalyticsPayload(body, r.Header.Get("Content-Type"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if single {
+		result := ingestEvent(0, messages[0], r)
+		switch result.Status {
+		case "rejected":
+			http.Error(w, result.Error, http.StatusBadRequest)
+		case "dropped":
+			w.Header().Set("Retry-After", "5")
+			http.Error(w, result.Error, http.StatusServiceUnavailable)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(map[string]string{"status": "accepted", "id": result.ID})
+		}
+		return
+	}
+
+	results := make([]eventResult, len(messages))
+	counts := map[string]int{"accepted": 0, "rejected": 0, "dropped": 0}
+	for i, message := range messages {
+		results[i] = ingestEvent(i, message, r)
+		counts[results[i].Status]++
+	}
+	if counts["dropped"] > 0 {
+		w.Header().Set("Retry-After", "5")
+	}
+
+	log.Printf("📊 Analytics batch: %d accepted, %d rejected, %d dropped",
+		counts["accepted"], counts["rejected"], counts["dropped"])
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"accepted": counts["accepted"],
+		"rejected": counts["rejected"],
+		"dropped":  counts["dropped"],
+		"results":  results,
+	})
+}
+
+// handleAnalyticsStats lets operators see whether events are being dropped
+func handleAnalyticsStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(analytics.Stats())
+}
+
+// readAnalyticsBody returns the request body, inflating it when the client
+// sent Content-Encoding: gzip
+func readAnalyticsBody(r *http.Request) ([]byte, error) {
+	var reader io.Reader = r.Body
+	if strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip body: %w", err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	body, err := io.ReadAll(io.LimitReader(reader, maxAnalyticsBody+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxAnalyticsBody {
+		return nil, fmt.Errorf("analytics payload exceeds %d bytes", maxAnalyticsBody)
+	}
+	return body, nil
+}
+
+// splitAnalyticsPayload breaks a submission into one raw message per event.
+// single is true when the body was a lone JSON object (the legacy format).
+func splitAnalyticsPayload(body []byte, contentType string) (messages []json.RawMessage, single bool, err error) {
+	if strings.Contains(contentType, "ndjson") || strings.Contains(contentType, "jsonl") {
+		for _, line := range bytes.Split(body, []byte("\n")) {
+			line = bytes.TrimSpace(line)
+			if len(line) > 0 {
+				messages = append(messages, json.RawMessage(line))
+			}
+		}
+		if len(messages) == 0 {
+			return nil, false, fmt.Errorf("no events in payload")
+		}
+		return messages, false, nil
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil, false, fmt.Errorf("empty payload")
+	}
+	if trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &messages); err != nil {
+			return nil, false, err
+		}
+		if len(messages) == 0 {
+			return nil, false, fmt.Errorf("no events in payload")
+		}
+		return messages, false, nil
+	}
+	return []json.RawMessage{json.RawMessage(trimmed)}, true, nil
+}
+
+// ingestEvent validates, enriches and queues one event
+func ingestEvent(index int, message json.RawMessage, r *http.Request) eventResult {
+	var raw rawEvent
+	if err := json.Unmarshal(message, &raw); err != nil {
+		return eventResult{Index: index, Status: "rejected", Error: err.Error()}
+	}
+	if err := raw.validate(); err != nil {
+		return eventResult{Index: index, Status: "rejected", Error: err.Error()}
+	}
+
+	event := enrichEvent(raw, r)
+	if !analytics.Enqueue(event) {
+		return eventResult{Index: index, Status: "dropped", Error: "Analytics buffer full"}
+	}
+	if event.Type == "product_view" {
+		productID, _ := event.Data["product_id"].(string)
+		recentViews.record(event.UserID, productID, event.ServerTime)
+	}
+	return eventResult{Index: index, Status: "accepted", ID: event.ID}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// ==================== JSONL SINK ====================
+
+// jsonlSink appends events as newline-delimited JSON, rotating to a new file
+// when the current one exceeds maxBytes or the UTC day changes
+type jsonlSink struct {
+	dir      string
+	maxBytes int64
+
+	file    *os.File
+	writer  *bufio.Writer
+	size    int64
+	day     string
+	written int
+}
+
+func newJSONLSink(dir string, maxBytes int64) (*jsonlSink, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &jsonlSink{dir: dir, maxBytes: maxBytes}, nil
+}
+
+func (s *jsonlSink) Name() string { return "jsonl" }
+
+func (s *jsonlSink) WriteBatch(events []AnalyticsEvent) error {
+	for _, event := range events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if err := s.rotateIfNeeded(event.ServerTime, int64(len(line)+1)); err != nil {
+			return err
+		}
+		n, err := s.writer.Write(append(line, '\n'))
+		s.size += int64(n)
+		if err != nil {
+			return err
+		}
+	}
+	return s.writer.Flush()
+}
+
+func (s *jsonlSink) rotateIfNeeded(at time.Time, next int64) error {
+	day := at.UTC().Format("20060102")
+	if s.file != nil && s.day == day && s.size+next <= s.maxBytes {
+		return nil
+	}
+	if err := s.closeFile(); err != nil {
+		return err
+	}
+
+	s.written++
+	name := fmt.Sprintf("events-%s-%s-%03d.jsonl", day, at.UTC().Format("150405"), s.written)
+	file, err := os.OpenFile(filepath.Join(s.dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	s.file = file
+	s.writer = bufio.NewWriter(file)
+	s.size = 0
+	s.day = day
+	return nil
+}
+
+func (s *jsonlSink) closeFile() error {
+	if s.file == nil {
+		return nil
+	}
+	if err := s.writer.Flush(); err != nil {
+		return err
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func (s *jsonlSink) Close() error { return s.closeFile() }
+
+// ==================== SQLITE SINK ====================
+
+const analyticsSchema = `
+CREATE TABLE IF NOT EXISTS analytics_events (
+	id               TEXT PRIMARY KEY,
+	event_type       TEXT NOT NULL,
+	user_id          TEXT,
+	mode             TEXT NOT NULL,
+	screen           TEXT,
+	server_time      TEXT NOT NULL,
+	client_timestamp TEXT,
+	data             TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_analytics_events_time ON analytics_events (server_time);
+CREATE INDEX IF NOT EXISTS idx_analytics_events_type ON analytics_events (event_type, mode);
+`
+
+// analyticsMigrations add columns introduced after the initial schema.
+// "duplicate column" errors mean the migration already ran.
+var analyticsMigrations = []string{
+	`ALTER TABLE analytics_events ADD COLUMN component_id TEXT`,
+	`ALTER TABLE analytics_events ADD COLUMN layout_revision TEXT`,
+	`ALTER TABLE analytics_events ADD COLUMN layout_mode TEXT`,
+	`CREATE INDEX IF NOT EXISTS idx_analytics_events_component ON analytics_events (component_id, layout_revision)`,
+	`ALTER TABLE analytics_events ADD COLUMN variant TEXT`,
+}
+
+// sqliteTimeFormat is fixed-width so server_time sorts and range-filters as text
+const sqliteTimeFormat = "2006-01-02T15:04:05.000Z"
+
+// sqliteSink inserts each batch in a single transaction
+type sqliteSink struct {
+	db *sql.DB
+}
+
+func newSQLiteSink(path string) (*sqliteSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(analyticsSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	for _, migration := range analyticsMigrations {
+		if _, err := db.Exec(migration); err != nil && !strings.Contains(err.Error(), "duplicate column") {
+			db.Close()
+			return nil, err
+		}
+	}
+	return &sqliteSink{db: db}, nil
+}
+
+func (s *sqliteSink) Name() string { return "sqlite" }
+
+func (s *sqliteSink) WriteBatch(events []AnalyticsEvent) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT OR IGNORE INTO analytics_events
+		(id, event_type, user_id, mode, screen, server_time, client_timestamp, data,
+		 component_id, layout_revision, layout_mode, variant)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, event := range events {
+		data, err := json.Marshal(event.Data)
+		if err != nil {
+			return err
+		}
+		var componentID, revision, layoutMode sql.NullString
+		if a := event.Attribution; a != nil {
+			componentID = sql.NullString{String: a.ComponentID, Valid: a.ComponentID != ""}
+			revision = sql.NullString{String: a.LayoutRevision, Valid: a.LayoutRevision != ""}
+			layoutMode = sql.NullString{String: a.Mode, Valid: a.Mode != ""}
+		}
+		_, err = stmt.Exec(event.ID, event.Type, event.UserID, event.Mode, event.Screen,
+			event.ServerTime.UTC().Format(sqliteTimeFormat), event.ClientTimestamp, string(data),
+			componentID, revision, layoutMode, sql.NullString{String: event.Variant, Valid: event.Variant != ""})
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *sqliteSink) Close() error { return s.db.Close() }
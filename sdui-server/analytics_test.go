@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memorySink collects written batches for assertions
+type memorySink struct {
+	mu     sync.Mutex
+	events []AnalyticsEvent
+	closed bool
+}
+
+func (s *memorySink) Name() string { return "memory" }
+
+func (s *memorySink) WriteBatch(events []AnalyticsEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, events...)
+	return nil
+}
+
+func (s *memorySink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func TestAnalyticsPipelineFlushesOnClose(t *testing.T) {
+	sink := &memorySink{}
+	p := newAnalyticsPipeline(16, 100, time.Hour, sink)
+	for i := 0; i < 3; i++ {
+		if !p.Enqueue(AnalyticsEvent{Type: "screen_view"}) {
+			t.Fatalf("event %d rejected", i)
+		}
+	}
+	p.Close()
+
+	if len(sink.events) != 3 {
+		t.Errorf("flushed %d events, want 3", len(sink.events))
+	}
+	if !sink.closed {
+		t.Error("sink not closed")
+	}
+}
+
+func TestAnalyticsPipelineEnqueueAfterClose(t *testing.T) {
+	sink := &memorySink{}
+	p := newAnalyticsPipeline(16, 100, time.Hour, sink)
+	p.Close()
+	p.Close() // a second Close is a no-op
+
+	// Handlers still finishing during shutdown must not panic
+	if p.Enqueue(AnalyticsEvent{Type: "screen_view"}) {
+		t.Error("Enqueue after Close accepted the event")
+	}
+	if got := p.dropped.Load(); got != 1 {
+		t.Errorf("dropped = %d, want 1", got)
+	}
+}
+
+func TestAnalyticsPipelineConcurrentClose(t *testing.T) {
+	p := newAnalyticsPipeline(4, 100, time.Hour)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				p.Enqueue(AnalyticsEvent{Type: "screen_view"})
+			}
+		}()
+	}
+	p.Close()
+	wg.Wait()
+}
+
+func TestRawEventValidate(t *testing.T) {
+	tests := []struct {
+		eventType string
+		wantErr   bool
+	}{
+		{"screen_view", false},
+		{"product_view", false},
+		{"checkout", false},
+		{"", true},
+		{"teleport", true},
+		{"purchase", true}, // logged by handleOrders at checkout
+	}
+	for _, tt := range tests {
+		err := rawEvent{EventType: tt.eventType}.validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validate(%q) = %v, want error %v", tt.eventType, err, tt.wantErr)
+		}
+	}
+}
+
+func TestAnalyticsStatsEndpoint(t *testing.T) {
+	previous := analytics
+	analytics = newAnalyticsPipeline(4, 100, time.Hour)
+	t.Cleanup(func() {
+		analytics.Close()
+		analytics = previous
+	})
+	analytics.Close()
+	analytics.Enqueue(AnalyticsEvent{Type: "screen_view"})
+
+	rec := httptest.NewRecorder()
+	handleAnalyticsStats(rec, httptest.NewRequest(http.MethodGet, "/api/admin/analytics", nil))
+	var stats map[string]float64
+	if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]float64{"accepted": 0, "dropped": 1, "buffered": 0, "capacity": 4}
+	for key, value := range want {
+		if stats[key] != value {
+			t.Errorf("%s = %v, want %v", key, stats[key], value)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+// ==================== LAYOUT ATTRIBUTION ====================
+
+// Attribution ties an analytics event back to the layout that produced it
+type Attribution struct {
+	Screen         string `json:"screen"`
+	Mode           string `json:"mode"`
+	LayoutRevision string `json:"layout_revision"`
+	ComponentID    string `json:"component_id"`
+}
+
+const trackingTokenSeparator = "\x1f"
+
+// trackingTokenPurpose prefixes what a tracking token's signature covers, so
+// it can never verify as a session token's signature or the other way round
+const trackingTokenPurpose = "trk" + trackingTokenSeparator
+
+// layoutRevision hashes everything in a config except its metadata, so the
+// same layout always gets the same revision id regardless of when it's served
+func layoutRevision(config map[string]interface{}) string {
+	stripped := make(map[string]interface{}, len(config))
+	for key, value := range config {
+		if key != "metadata" {
+			stripped[key] = value
+		}
+	}
+	encoded, _ := json.Marshal(stripped)
+	sum := sha256.Sum256(encoded)
+	return "rev_" + hex.EncodeToString(sum[:6])
+}
+
+// stampLayout records the layout revision in metadata and gives every
+// component (children included) a tracking token clients echo back in
+// impression/tap events
+func stampLayout(config map[string]interface{}, screen, mode string) {
+	revision := layoutRevision(config)
+
+	if metadata, ok := config["metadata"].(map[string]interface{}); ok {
+		metadata["layout_revision"] = revision
+	}
+
+	components, _ := config["components"].([]interface{})
+	stampComponents(components, Attribution{Screen: screen, Mode: mode, LayoutRevision: revision})
+}
+
+func stampComponents(components []interface{}, base Attribution) {
+	for _, c := range components {
+		component, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if id, ok := component["id"].(string); ok {
+			attribution := base
+			attribution.ComponentID = id
+			component["tracking_token"] = encodeTrackingToken(attribution)
+		}
+		if children, ok := component["children"].([]interface{}); ok {
+			stampComponents(children, base)
+		}
+	}
+}
+
+// encodeTrackingToken signs the attribution like session tokens, as
+// base64url(fields).base64url(hmac), so clients can't attribute events to
+// layouts or components they weren't served
+func encodeTrackingToken(a Attribution) string {
+	raw := strings.Join([]string{a.Screen, a.Mode, a.LayoutRevision, a.ComponentID}, trackingTokenSeparator)
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(raw))
+	return encoded + "." + tokenSignature(trackingTokenPurpose+encoded)
+}
+
+func decodeTrackingToken(token string) (Attribution, bool) {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok || !validSignature(trackingTokenPurpose+encoded, sig) {
+		return Attribution{}, false
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Attribution{}, false
+	}
+	parts := strings.Split(string(raw), trackingTokenSeparator)
+	if len(parts) != 4 {
+		return Attribution{}, false
+	}
+	return Attribution{
+		Screen:         parts[0],
+		Mode:           parts[1],
+		LayoutRevision: parts[2],
+		ComponentID:    parts[3],
+	}, true
+}
+
+// attributeEvent joins an event back to (screen, mode, layout revision,
+// component id) using the tracking token, falling back to a bare
+// component_id when the client didn't send one
+func attributeEvent(event *AnalyticsEvent) {
+	if token, ok := event.Data["tracking_token"].(string); ok {
+		if attribution, ok := decodeTrackingToken(token); ok {
+			event.Attribution = &attribution
+			if event.Screen == "" {
+				event.Screen = attribution.Screen
+			}
+			return
+		}
+	}
+	if componentID, ok := event.Data["component_id"].(string); ok {
+		event.Attribution = &Attribution{
+			Screen:      event.Screen,
+			Mode:        event.Mode,
+			ComponentID: componentID,
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestTrackingToken(t *testing.T) {
+	attribution := Attribution{Screen: "/", Mode: "morning", LayoutRevision: "rev_abc", ComponentID: "morning-deals"}
+	token := encodeTrackingToken(attribution)
+	encoded, _, _ := strings.Cut(token, ".")
+
+	forged := func(a Attribution) string {
+		raw := strings.Join([]string{a.Screen, a.Mode, a.LayoutRevision, a.ComponentID}, trackingTokenSeparator)
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+	other := attribution
+	other.ComponentID = "flash-countdown"
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"signed token", token, true},
+		{"unsigned token", encoded, false},
+		{"unsigned forgery", forged(other), false},
+		{"signature from another token", forged(other) + token[len(encoded):], false},
+		{"truncated signature", token[:len(token)-2], false},
+		{"signed as a session token", encoded + "." + tokenSignature(encoded), false},
+		{"session token", signToken(tokenClaims{Subject: "guest_1", Kind: "guest"}), false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := decodeTrackingToken(tt.token)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && got != attribution {
+				t.Errorf("decoded %+v, want %+v", got, attribution)
+			}
+		})
+	}
+	if _, err := parseToken(token); err == nil {
+		t.Error("tracking token accepted as a session token")
+	}
+}
+
+func TestAttributeEvent(t *testing.T) {
+	attribution := Attribution{Screen: "/", Mode: "evening", LayoutRevision: "rev_1", ComponentID: "evening-banner"}
+	tests := []struct {
+		name string
+		data map[string]interface{}
+		want *Attribution
+	}{
+		{"tracking token", map[string]interface{}{"tracking_token": encodeTrackingToken(attribution)}, &attribution},
+		{"bare component id", map[string]interface{}{"component_id": "evening-grid"},
+			&Attribution{Screen: "/search", Mode: "night", ComponentID: "evening-grid"}},
+		{"forged token falls back to component id", map[string]interface{}{"tracking_token": "bogus.sig", "component_id": "evening-grid"},
+			&Attribution{Screen: "/search", Mode: "night", ComponentID: "evening-grid"}},
+		{"nothing to attribute", map[string]interface{}{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := AnalyticsEvent{Type: "tap", Data: tt.data, Screen: "/search", Mode: "night"}
+			attributeEvent(&event)
+			switch {
+			case tt.want == nil && event.Attribution != nil:
+				t.Errorf("attributed %+v, want none", *event.Attribution)
+			case tt.want != nil && (event.Attribution == nil || *event.Attribution != *tt.want):
+				t.Errorf("attributed %+v, want %+v", event.Attribution, *tt.want)
+			}
+		})
+	}
+}
+
+func TestStampLayoutTokens(t *testing.T) {
+	config := map[string]interface{}{
+		"metadata": map[string]interface{}{},
+		"components": []interface{}{
+			map[string]interface{}{"id": "row", "children": []interface{}{
+				map[string]interface{}{"id": "child"},
+			}},
+		},
+	}
+	stampLayout(config, "/", "day")
+
+	revision, _ := config["metadata"].(map[string]interface{})["layout_revision"].(string)
+	row := config["components"].([]interface{})[0].(map[string]interface{})
+	child := row["children"].([]interface{})[0].(map[string]interface{})
+	for id, component := range map[string]map[string]interface{}{"row": row, "child": child} {
+		token, _ := component["tracking_token"].(string)
+		got, ok := decodeTrackingToken(token)
+		want := Attribution{Screen: "/", Mode: "day", LayoutRevision: revision, ComponentID: id}
+		if !ok || got != want {
+			t.Errorf("%s: decoded %+v (ok %v), want %+v", id, got, ok, want)
+		}
+	}
+}
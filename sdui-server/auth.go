@@ -0,0 +1,454 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==================== TOKENS ====================
+
+const (
+	accessTokenTTL  = 1 * time.Hour
+	refreshTokenTTL = 30 * 24 * time.Hour
+	guestTokenTTL   = 30 * 24 * time.Hour
+)
+
+var (
+	errInvalidToken    = errors.New("invalid token")
+	errExpiredToken    = errors.New("token expired")
+	errInvalidLogin    = errors.New("invalid email or password")
+	errEmailTaken      = errors.New("email already registered")
+	errInvalidPassword = errors.New("password must be at least 8 characters")
+)
+
+// tokenClaims is the signed payload carried inside every session token
+type tokenClaims struct {
+	Subject   string `json:"sub"`
+	Kind      string `json:"kind"` // "access", "refresh" or "guest"
+	Guest     bool   `json:"guest,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+var authSecret = loadAuthSecret()
+
+// loadAuthSecret reads the signing key from SDUI_AUTH_SECRET, falling back to
+// a random per-process key (tokens then die with the process)
+func loadAuthSecret() []byte {
+	if secret := os.Getenv("SDUI_AUTH_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+	key := make([]byte, 32)
+	rand.Read(key)
+	log.Printf("🔐 SDUI_AUTH_SECRET not set, using an ephemeral signing key")
+	return key
+}
+
+// signToken encodes claims as base64url(payload).base64url(hmac)
+func signToken(claims tokenClaims) string {
+	payload, _ := json.Marshal(claims)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + tokenSignature(encoded)
+}
+
+// tokenSignature is base64url(hmac(encoded)) under authSecret
+func tokenSignature(encoded string) string {
+	mac := hmac.New(sha256.New, authSecret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// validSignature reports whether sig is encoded's signature, in constant time
+func validSignature(encoded, sig string) bool {
+	return hmac.Equal([]byte(sig), []byte(tokenSignature(encoded)))
+}
+
+func parseToken(token string) (tokenClaims, error) {
+	var claims tokenClaims
+
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return claims, errInvalidToken
+	}
+
+	if !validSignature(encoded, sig) {
+		return claims, errInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return claims, errInvalidToken
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, errInvalidToken
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return claims, errExpiredToken
+	}
+	return claims, nil
+}
+
+func issueToken(subject, kind string, guest bool, ttl time.Duration) (string, time.Time) {
+	now := time.Now()
+	expires := now.Add(ttl)
+	return signToken(tokenClaims{
+		Subject:   subject,
+		Kind:      kind,
+		Guest:     guest,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expires.Unix(),
+	}), expires
+}
+
+// issueSessionTokens returns the access/refresh pair handed to a logged-in user
+func issueSessionTokens(user *User) map[string]interface{} {
+	access, accessExp := issueToken(user.ID, "access", false, accessTokenTTL)
+	refresh, _ := issueToken(user.ID, "refresh", false, refreshTokenTTL)
+
+	return map[string]interface{}{
+		"access_token":  access,
+		"refresh_token": refresh,
+		"token_type":    "Bearer",
+		"expires_at":    accessExp.Format(time.RFC3339),
+		"user":          user.publicView(),
+	}
+}
+
+// ==================== USERS ====================
+
+type User struct {
+	ID           string    `json:"id"`
+	Email        string    `json:"email"`
+	FirstName    string    `json:"first_name"`
+	PasswordHash string    `json:"-"`
+	Salt         string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (u *User) publicView() map[string]interface{} {
+	return map[string]interface{}{
+		"id":         u.ID,
+		"email":      u.Email,
+		"first_name": u.FirstName,
+		"created_at": u.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+type userStore struct {
+	mu      sync.RWMutex
+	byID    map[string]*User
+	byEmail map[string]*User
+}
+
+var users = &userStore{
+	byID:    make(map[string]*User),
+	byEmail: make(map[string]*User),
+}
+
+func hashPassword(password, salt string) string {
+	key, _ := pbkdf2.Key(sha256.New, password, []byte(salt), 100_000, 32)
+	return hex.EncodeToString(key)
+}
+
+func (s *userStore) register(email, password, firstName string) (*User, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" || !strings.Contains(email, "@") {
+		return nil, fmt.Errorf("invalid email %q", email)
+	}
+	if len(password) < 8 {
+		return nil, errInvalidPassword
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.byEmail[email]; exists {
+		return nil, errEmailTaken
+	}
+
+	salt := randomID(16)
+	user := &User{
+		ID:           "user_" + randomID(8),
+		Email:        email,
+		FirstName:    firstName,
+		PasswordHash: hashPassword(password, salt),
+		Salt:         salt,
+		CreatedAt:    time.Now(),
+	}
+	s.byID[user.ID] = user
+	s.byEmail[email] = user
+	return user, nil
+}
+
+func (s *userStore) authenticate(email, password string) (*User, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	s.mu.RLock()
+	user, ok := s.byEmail[email]
+	s.mu.RUnlock()
+
+	if !ok {
+		// Burn the same time as a real check so emails can't be enumerated
+		hashPassword(password, "unknown-user")
+		return nil, errInvalidLogin
+	}
+	hash := hashPassword(password, user.Salt)
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(user.PasswordHash)) != 1 {
+		return nil, errInvalidLogin
+	}
+	return user, nil
+}
+
+func (s *userStore) get(id string) (*User, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	user, ok := s.byID[id]
+	return user, ok
+}
+
+func randomID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ==================== REQUEST CONTEXT ====================
+
+// Session is the authenticated identity attached to a request
+type Session struct {
+	UserID string
+	Guest  bool
+}
+
+type sessionKey struct{}
+
+// withAuth resolves a Bearer token into a Session on the request context.
+// Requests without a token pass through anonymously; bad tokens are rejected.
+func withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			http.Error(w, "Authorization header must be a Bearer token", http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := parseToken(token)
+		if err != nil || claims.Kind == "refresh" {
+			http.Error(w, errInvalidToken.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		session := &Session{UserID: claims.Subject, Guest: claims.Guest}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), sessionKey{}, session)))
+	})
+}
+
+func sessionFromContext(ctx context.Context) *Session {
+	session, _ := ctx.Value(sessionKey{}).(*Session)
+	return session
+}
+
+// currentUserID returns the authenticated (or guest) user id, or "" when anonymous
+func currentUserID(r *http.Request) string {
+	if session := sessionFromContext(r.Context()); session != nil {
+		return session.UserID
+	}
+	return ""
+}
+
+// requireSession wraps handlers that need a guest or user identity
+func requireSession(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if sessionFromContext(r.Context()) == nil {
+			http.Error(w, "Authentication required", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+var adminKey = loadAdminKey()
+
+// loadAdminKey reads the admin shared secret from SDUI_ADMIN_KEY
+func loadAdminKey() string {
+	key := os.Getenv("SDUI_ADMIN_KEY")
+	if key == "" {
+		log.Printf("🔒 SDUI_ADMIN_KEY not set, admin endpoints are disabled")
+	}
+	return key
+}
+
+// requireAdmin guards internal endpoints with the SDUI_ADMIN_KEY shared
+// secret, sent as X-Admin-Key. Without the env var set they fail closed.
+func requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if adminKey == "" {
+			http.Error(w, "Admin endpoints disabled: SDUI_ADMIN_KEY not set", http.StatusServiceUnavailable)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Admin-Key")), []byte(adminKey)) != 1 {
+			http.Error(w, "Admin key required", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// ==================== AUTH HANDLERS ====================
+
+type credentials struct {
+	Email     string `json:"email"`
+	Password  string `json:"password"`
+	FirstName string `json:"first_name"`
+}
+
+func decodeCredentials(w http.ResponseWriter, r *http.Request) (credentials, bool) {
+	var creds credentials
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return creds, false
+	}
+	if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return creds, false
+	}
+	return creds, true
+}
+
+// mergeGuestSession moves a guest's cart, favorites and history onto the account
+// that just logged in, when the request was made with a guest token
+func mergeGuestSession(r *http.Request, user *User) {
+	session := sessionFromContext(r.Context())
+	if session == nil || !session.Guest {
+		return
+	}
+	mergeUserData(session.UserID, user.ID)
+	recentViews.merge(session.UserID, user.ID)
+	log.Printf("🔀 Merged guest session %s into %s", session.UserID, user.ID)
+}
+
+func handleRegister(w http.ResponseWriter, r *http.Request) {
+	creds, ok := decodeCredentials(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := users.register(creds.Email, creds.Password, creds.FirstName)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errEmailTaken) {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	mergeGuestSession(r, user)
+
+	log.Printf("👤 Registered %s (%s)", user.ID, user.Email)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(issueSessionTokens(user))
+}
+
+func handleLogin(w http.ResponseWriter, r *http.Request) {
+	creds, ok := decodeCredentials(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := users.authenticate(creds.Email, creds.Password)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	mergeGuestSession(r, user)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(issueSessionTokens(user))
+}
+
+func handleRefresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	claims, err := parseToken(body.RefreshToken)
+	if err != nil || claims.Kind != "refresh" {
+		http.Error(w, errInvalidToken.Error(), http.StatusUnauthorized)
+		return
+	}
+	user, ok := users.get(claims.Subject)
+	if !ok {
+		http.Error(w, errInvalidToken.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(issueSessionTokens(user))
+}
+
+// handleGuestSession issues an anonymous identity so carts and favorites
+// can be kept before the shopper signs in
+func handleGuestSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	guestID := "guest_" + randomID(8)
+	token, expires := issueToken(guestID, "guest", true, guestTokenTTL)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_at":   expires.Format(time.RFC3339),
+		"guest_id":     guestID,
+	})
+}
+
+func handleMe(w http.ResponseWriter, r *http.Request) {
+	session := sessionFromContext(r.Context())
+
+	response := map[string]interface{}{
+		"id":    session.UserID,
+		"guest": session.Guest,
+	}
+	if user, ok := users.get(session.UserID); ok {
+		response = user.publicView()
+		response["guest"] = false
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseToken(t *testing.T) {
+	valid, _ := issueToken("user_1", "access", false, time.Hour)
+	expired, _ := issueToken("user_1", "access", false, -time.Second)
+	encoded, sig, _ := strings.Cut(valid, ".")
+
+	// Same signature over a payload claiming another user
+	payload, _ := base64.RawURLEncoding.DecodeString(encoded)
+	tampered := base64.RawURLEncoding.EncodeToString([]byte(strings.Replace(string(payload), "user_1", "user_2", 1))) + "." + sig
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"valid", valid, nil},
+		{"expired", expired, errExpiredToken},
+		{"tampered payload", tampered, errInvalidToken},
+		{"missing signature", encoded, errInvalidToken},
+		{"wrong signature", encoded + "." + tokenSignature("other"), errInvalidToken},
+		{"signed garbage", "bm90LWpzb24." + tokenSignature("bm90LWpzb24"), errInvalidToken},
+		{"empty", "", errInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := parseToken(tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (claims.Subject != "user_1" || claims.Kind != "access") {
+				t.Errorf("claims = %+v", claims)
+			}
+		})
+	}
+}
+
+func TestWithAuth(t *testing.T) {
+	access, _ := issueToken("user_1", "access", false, time.Hour)
+	guest, _ := issueToken("guest_1", "guest", true, time.Hour)
+	refresh, _ := issueToken("user_1", "refresh", false, time.Hour)
+	expired, _ := issueToken("user_1", "access", false, -time.Second)
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantUser      string
+	}{
+		{"anonymous", "", http.StatusOK, ""},
+		{"access token", "Bearer " + access, http.StatusOK, "user_1"},
+		{"guest token", "Bearer " + guest, http.StatusOK, "guest_1"},
+		{"refresh token is not a session", "Bearer " + refresh, http.StatusUnauthorized, ""},
+		{"expired token", "Bearer " + expired, http.StatusUnauthorized, ""},
+		{"not a bearer token", "Basic dXNlcjpwYXNz", http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotUser string
+			handler := withAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotUser = currentUserID(r)
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus || gotUser != tt.wantUser {
+				t.Errorf("status %d, user %q; want %d, %q", rec.Code, gotUser, tt.wantStatus, tt.wantUser)
+			}
+		})
+	}
+}
+
+func TestRequireAdmin(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured string
+		sent       string
+		want       int
+	}{
+		{"no key configured fails closed", "", "", http.StatusServiceUnavailable},
+		{"no key configured ignores any header", "", "anything", http.StatusServiceUnavailable},
+		{"missing header", "s3cret", "", http.StatusForbidden},
+		{"wrong key", "s3cret", "guess", http.StatusForbidden},
+		{"key prefix", "s3cret", "s3cre", http.StatusForbidden},
+		{"correct key", "s3cret", "s3cret", http.StatusOK},
+	}
+
+	previous := adminKey
+	t.Cleanup(func() { adminKey = previous })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adminKey = tt.configured
+			handler := requireAdmin(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/api/reports/overview", nil)
+			if tt.sent != "" {
+				req.Header.Set("X-Admin-Key", tt.sent)
+			}
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
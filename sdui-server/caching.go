@@ -0,0 +1,66 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ==================== HTTP CACHING ====================
+
+// contentETag is a strong validator for an exact response body
+func contentETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// etagMatches applies If-None-Match's weak comparison: a listed tag
+// matches regardless of W/ prefixes, and "*" matches anything
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// untilNextMode is how long what's served now can stay current: every
+// layout changes at the next mode boundary
+func untilNextMode(now time.Time) time.Duration {
+	_, end := currentModeWindow(now)
+	return max(end.Sub(now), 0)
+}
+
+// setCacheHeaders sets the validator and a max-age of at most maxAge that
+// never runs past the next mode boundary, after which caches must
+// revalidate. A maxAge of 0 asks caches to revalidate on every use.
+func setCacheHeaders(w http.ResponseWriter, etag, scope string, maxAge time.Duration, now time.Time) {
+	w.Header().Set("ETag", etag)
+	if maxAge <= 0 {
+		w.Header().Set("Cache-Control", scope+", no-cache")
+		return
+	}
+	seconds := int(min(maxAge, untilNextMode(now)).Seconds())
+	w.Header().Set("Cache-Control", fmt.Sprintf("%s, max-age=%d, must-revalidate", scope, seconds))
+}
+
+// notModified answers a conditional GET with 304 when the client's copy
+// still matches. Cache headers must already be set.
+func notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if !etagMatches(r.Header.Get("If-None-Match"), etag) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		ifNoneMatch, etag string
+		want              bool
+	}{
+		{"", `"abc"`, false},
+		{`"abc"`, `"abc"`, true},
+		{`"abc"`, `"abd"`, false},
+		{`W/"abc"`, `"abc"`, true},
+		{`"abc"`, `W/"abc"`, true},
+		{`"x", W/"abc" , "y"`, `W/"abc"`, true},
+		{`"x", "y"`, `W/"abc"`, false},
+		{`*`, `"anything"`, true},
+		{`W/"cfg_1"`, `W/"cfg_1.cbor"`, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.ifNoneMatch, tt.etag); got != tt.want {
+			t.Errorf("etagMatches(%q, %q) = %v, want %v", tt.ifNoneMatch, tt.etag, got, tt.want)
+		}
+	}
+}
+
+func TestSetCacheHeaders(t *testing.T) {
+	day := func(hour, minute, second int) time.Time {
+		return time.Date(2025, 6, 1, hour, minute, second, 0, time.Local)
+	}
+	tests := []struct {
+		name   string
+		maxAge time.Duration
+		now    time.Time
+		want   string
+	}{
+		{"mid-window", time.Minute, day(10, 0, 0), "private, max-age=60, must-revalidate"},
+		{"just before a boundary", time.Minute, day(11, 59, 45), "private, max-age=15, must-revalidate"},
+		{"on a boundary", time.Minute, day(12, 0, 0), "private, max-age=60, must-revalidate"},
+		{"last second of the day", time.Minute, day(23, 59, 59), "private, max-age=1, must-revalidate"},
+		{"revalidate every use", 0, day(10, 0, 0), "private, no-cache"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			setCacheHeaders(rec, `"v1"`, "private", tt.maxAge, tt.now)
+			if got := rec.Header().Get("Cache-Control"); got != tt.want {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.want)
+			}
+			if got := rec.Header().Get("ETag"); got != `"v1"` {
+				t.Errorf("ETag = %q", got)
+			}
+		})
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	tests := []struct {
+		method, ifNoneMatch string
+		want                bool
+	}{
+		{http.MethodGet, `"v1"`, true},
+		{http.MethodHead, `"v1"`, true},
+		{http.MethodGet, `"v0"`, false},
+		{http.MethodGet, "", false},
+		{http.MethodPost, `"v1"`, false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/", nil)
+		if tt.ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", tt.ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		got := notModified(rec, req, `"v1"`)
+		if got != tt.want {
+			t.Errorf("%s If-None-Match %q: notModified = %v, want %v", tt.method, tt.ifNoneMatch, got, tt.want)
+		}
+		if got && rec.Code != http.StatusNotModified {
+			t.Errorf("%s If-None-Match %q: status %d, want 304", tt.method, tt.ifNoneMatch, rec.Code)
+		}
+	}
+}
+
+func TestProductDetailRevalidatesAndRecordsViews(t *testing.T) {
+	quietLogs(t)
+	userID := "guest_cache_test"
+	token, _ := issueToken(userID, "access", true, time.Hour)
+	handler := withAuth(http.HandlerFunc(handleProductDetail))
+
+	get := func(productID, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/products/"+productID, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get("prod_4", "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("status %d, ETag %q", first.Code, etag)
+	}
+	if got := first.Header().Get("Cache-Control"); got != "public, no-cache" {
+		t.Errorf("Cache-Control = %q, want public, no-cache", got)
+	}
+
+	get("prod_5", "")
+	revalidated := get("prod_4", etag)
+	if revalidated.Code != http.StatusNotModified {
+		t.Errorf("revalidation status %d, want 304", revalidated.Code)
+	}
+	if revalidated.Body.Len() != 0 {
+		t.Errorf("304 carried a %d byte body", revalidated.Body.Len())
+	}
+
+	// The revalidated view still counts as the most recent one
+	if ids := recentlyViewedIDs(userID); len(ids) < 2 || ids[0] != "prod_4" || ids[1] != "prod_5" {
+		t.Errorf("recently viewed = %v, want prod_4 then prod_5", ids)
+	}
+}
+
+func TestUiConfigConditionalGet(t *testing.T) {
+	quietLogs(t)
+	first := getUiConfig(t, "/api/ui-config?screen=/search&locale=en", nil)
+	etag := first.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("no ETag")
+	}
+	if got := first.Header().Get("Cache-Control"); !strings.HasPrefix(got, "private, max-age=") || !strings.HasSuffix(got, ", must-revalidate") {
+		t.Errorf("Cache-Control = %q, want a private max-age with must-revalidate", got)
+	}
+
+	tests := []struct {
+		name    string
+		target  string
+		headers map[string]string
+		want    int
+	}{
+		{"same representation", "/api/ui-config?screen=/search&locale=en", map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{"other locale", "/api/ui-config?screen=/search&locale=fr", map[string]string{"If-None-Match": etag}, http.StatusOK},
+		{"other format", "/api/ui-config?screen=/search&locale=en", map[string]string{"If-None-Match": etag, "Accept": "application/cbor"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := getUiConfig(t, tt.target, tt.headers)
+			if rec.Code != tt.want {
+				t.Errorf("status %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
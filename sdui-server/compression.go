@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// ==================== RESPONSE COMPRESSION ====================
+
+// Bodies smaller than this go out as-is: below about a packet the
+// compressor's framing and CPU cost more than the bytes saved
+const compressionMinBytes = 1024
+
+// supportedEncodings in order of preference when a client rates them equally
+var supportedEncodings = []string{"br", "gzip"}
+
+// negotiateEncoding picks a content coding from Accept-Encoding, or ""
+// for identity
+func negotiateEncoding(r *http.Request) string {
+	header := r.Header.Get("Accept-Encoding")
+	if header == "" {
+		return ""
+	}
+
+	weights := make(map[string]float64)
+	wildcard := -1.0
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		weight := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				weight = parsed
+			}
+		}
+		if coding == "*" {
+			wildcard = weight
+			continue
+		}
+		weights[coding] = weight
+	}
+
+	best, bestWeight := "", 0.0
+	for _, coding := range supportedEncodings {
+		weight, listed := weights[coding]
+		if !listed {
+			weight = wildcard
+		}
+		if weight > bestWeight {
+			best, bestWeight = coding, weight
+		}
+	}
+	return best
+}
+
+var (
+	gzipWriters   = sync.Pool{New: func() interface{} { w, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression); return w }}
+	brotliWriters = sync.Pool{New: func() interface{} { return brotli.NewWriterLevel(nil, 5) }}
+)
+
+// compress encodes body with a content coding from negotiateEncoding
+func compress(body []byte, encoding string) []byte {
+	var buf bytes.Buffer
+	switch encoding {
+	case "gzip":
+		zw := gzipWriters.Get().(*gzip.Writer)
+		zw.Reset(&buf)
+		zw.Write(body)
+		zw.Close()
+		gzipWriters.Put(zw)
+	case "br":
+		bw := brotliWriters.Get().(*brotli.Writer)
+		bw.Reset(&buf)
+		bw.Write(body)
+		bw.Close()
+		brotliWriters.Put(bw)
+	default:
+		return body
+	}
+	return buf.Bytes()
+}
+
+// compressedCacheMax bounds how many compressed bodies one render cache
+// entry keeps; personalized revisions of a layout each take a slot
+const compressedCacheMax = 32
+
+// compressedCache keeps compressed copies of a render cache entry's bodies
+// by format, coding and config revision. The revision leaves out clock
+// readings, which tick every second, so a copy is only reused while the
+// body is byte-for-byte the same: under load, every request for the same
+// revision within a second shares one compression.
+type compressedCache struct {
+	mu     sync.Mutex
+	bodies map[compressedKey]compressedBody
+}
+
+type compressedKey struct {
+	format, encoding, revision string
+}
+
+type compressedBody struct {
+	sum  [sha256.Size]byte
+	data []byte
+}
+
+func (c *compressedCache) compress(key compressedKey, body []byte) []byte {
+	if c == nil || key.revision == "" {
+		return compress(body, key.encoding)
+	}
+	sum := sha256.Sum256(body)
+	c.mu.Lock()
+	cached, ok := c.bodies[key]
+	c.mu.Unlock()
+	if ok && cached.sum == sum {
+		return cached.data
+	}
+
+	data := compress(body, key.encoding)
+	c.mu.Lock()
+	if c.bodies == nil || len(c.bodies) >= compressedCacheMax {
+		c.bodies = make(map[compressedKey]compressedBody)
+	}
+	c.bodies[key] = compressedBody{sum: sum, data: data}
+	c.mu.Unlock()
+	return data
+}
+
+// writeCompressed writes body with the best coding the client accepts,
+// reusing a copy from cache when key's body was compressed already (cache
+// may be nil). Headers must already be set, including
+// Vary: Accept-Encoding, except Content-Encoding and Content-Length.
+func writeCompressed(w http.ResponseWriter, r *http.Request, body []byte, cache *compressedCache, key compressedKey) {
+	encoding := negotiateEncoding(r)
+	if encoding != "" && len(body) >= compressionMinBytes {
+		key.encoding = encoding
+		body = cache.compress(key, body)
+		w.Header().Set("Content-Encoding", encoding)
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.Write(body)
+}
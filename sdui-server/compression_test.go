@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		want           string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"br", "br"},
+		{"gzip, br", "br"},
+		{"GZIP", "gzip"},
+		{"gzip;q=1.0, br;q=0.5", "gzip"},
+		{"br;q=0, gzip", "gzip"},
+		{"br;q=0, gzip;q=0", ""},
+		{"*", "br"},
+		{"*;q=0.1, gzip;q=0.5", "gzip"},
+		{"*, br;q=0", "gzip"},
+		{"*;q=0", ""},
+		{"deflate", ""},
+		{"gzip;q=bogus", "gzip"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+		}
+		if got := negotiateEncoding(req); got != tt.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.acceptEncoding, got, tt.want)
+		}
+	}
+}
+
+func decompress(t *testing.T, body []byte, encoding string) []byte {
+	t.Helper()
+	var reader io.Reader
+	switch encoding {
+	case "gzip":
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = zr
+	case "br":
+		reader = brotli.NewReader(bytes.NewReader(body))
+	default:
+		return body
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("decoding %s: %v", encoding, err)
+	}
+	return decoded
+}
+
+func TestWriteCompressed(t *testing.T) {
+	large := []byte(strings.Repeat(`{"type":"product_card"},`, 200))
+	small := []byte(`{"ok":true}`)
+
+	tests := []struct {
+		name           string
+		body           []byte
+		acceptEncoding string
+		wantEncoding   string
+	}{
+		{"gzip", large, "gzip", "gzip"},
+		{"brotli", large, "br, gzip", "br"},
+		{"identity", large, "", ""},
+		{"refused coding", large, "br;q=0", ""},
+		{"small body stays plain", small, "gzip", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rec := httptest.NewRecorder()
+			writeCompressed(rec, req, tt.body, nil, compressedKey{})
+
+			if got := rec.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if got, want := rec.Header().Get("Content-Length"), rec.Body.Len(); got != strconv.Itoa(want) {
+				t.Errorf("Content-Length = %s, body is %d bytes", got, want)
+			}
+			if got := decompress(t, rec.Body.Bytes(), tt.wantEncoding); !bytes.Equal(got, tt.body) {
+				t.Error("decompressed body differs from the original")
+			}
+		})
+	}
+}
+
+func TestCompressedCache(t *testing.T) {
+	var cache compressedCache
+	body := []byte(strings.Repeat(`{"server_time":"12:00:00"}`, 100))
+	ticked := []byte(strings.Repeat(`{"server_time":"12:00:01"}`, 100))
+	key := compressedKey{format: "json", encoding: "gzip", revision: "cfg_1"}
+
+	first := cache.compress(key, body)
+	if again := cache.compress(key, body); &again[0] != &first[0] {
+		t.Error("identical body compressed twice")
+	}
+	next := cache.compress(key, ticked)
+	if &next[0] == &first[0] || !bytes.Equal(decompress(t, next, "gzip"), ticked) {
+		t.Error("a body whose clocks moved reused the stale copy")
+	}
+
+	// Other revisions and codings get slots of their own
+	other := cache.compress(compressedKey{format: "json", encoding: "gzip", revision: "cfg_2"}, body)
+	brotli := cache.compress(compressedKey{format: "json", encoding: "br", revision: "cfg_1"}, ticked)
+	if again := cache.compress(key, ticked); &again[0] != &next[0] {
+		t.Error("cfg_1 lost its copy to other slots")
+	}
+	if !bytes.Equal(decompress(t, other, "gzip"), body) || !bytes.Equal(decompress(t, brotli, "br"), ticked) {
+		t.Error("slot bodies differ from their input")
+	}
+
+	for i := 0; i < compressedCacheMax*2; i++ {
+		cache.compress(compressedKey{format: "json", encoding: "gzip", revision: strconv.Itoa(i)}, body)
+	}
+	if len(cache.bodies) > compressedCacheMax {
+		t.Errorf("cache holds %d bodies, want at most %d", len(cache.bodies), compressedCacheMax)
+	}
+}
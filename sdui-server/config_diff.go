@@ -0,0 +1,269 @@
+package main
+
+import (
+	"container/list"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==================== CONFIG REVISIONS ====================
+
+// Every served ui-config document gets a config_revision: a hash of the
+// whole document bar its clock readings, where layout_revision ignores
+// per-request data altogether.
+// Clients that send the revision they hold, as X-Base-Revision or
+// ?base_revision=, get an RFC 6902 JSON Patch to the current document
+// instead of the full thing when the server still remembers that base.
+
+// Each (screen, mode) keeps its own history, bounded by the bytes it holds
+// rather than a count so personalized revisions of a busy screen only push
+// out that screen's older documents. Clients name the screen, so the number
+// of histories is capped too.
+const (
+	configHistoryMaxBytes   = 512 << 10
+	configHistoryMaxScreens = 32
+)
+
+type servedConfig struct {
+	revision string
+	encoded  []byte // decoded only when a client diffs against it
+}
+
+// document decodes the config as generic JSON, as clients see it
+func (c servedConfig) document() interface{} {
+	var document interface{}
+	json.Unmarshal(c.encoded, &document)
+	return document
+}
+
+type configHistoryKey struct {
+	screen string
+	mode   string
+}
+
+// configHistories holds one configHistory per (screen, mode), forgetting
+// the least recently used once there are more than maxScreens
+type configHistories struct {
+	mu         sync.Mutex
+	maxBytes   int
+	maxScreens int
+	order      *list.List // of *configHistory, most recently used first
+	histories  map[configHistoryKey]*list.Element
+}
+
+var servedConfigs = newConfigHistories(configHistoryMaxBytes, configHistoryMaxScreens)
+
+func newConfigHistories(maxBytes, maxScreens int) *configHistories {
+	return &configHistories{
+		maxBytes:   maxBytes,
+		maxScreens: maxScreens,
+		order:      list.New(),
+		histories:  make(map[configHistoryKey]*list.Element),
+	}
+}
+
+// forScreen returns the screen's history in the given mode, starting one
+// when there is none
+func (h *configHistories) forScreen(screen, mode string) *configHistory {
+	key := configHistoryKey{screen: screen, mode: mode}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if element, ok := h.histories[key]; ok {
+		h.order.MoveToFront(element)
+		return element.Value.(*configHistory)
+	}
+	history := newConfigHistory(h.maxBytes)
+	history.key = key
+	h.histories[key] = h.order.PushFront(history)
+
+	if h.order.Len() > h.maxScreens {
+		oldest := h.order.Remove(h.order.Back()).(*configHistory)
+		delete(h.histories, oldest.key)
+	}
+	return history
+}
+
+// configHistory is an LRU of one screen's served documents by revision
+type configHistory struct {
+	mu       sync.Mutex
+	key      configHistoryKey
+	maxBytes int
+	bytes    int
+	order    *list.List // of servedConfig, most recently used first
+	entries  map[string]*list.Element
+}
+
+func newConfigHistory(maxBytes int) *configHistory {
+	return &configHistory{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (h *configHistory) remember(served servedConfig) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if element, ok := h.entries[served.revision]; ok {
+		h.bytes -= len(element.Value.(servedConfig).encoded)
+		element.Value = served
+		h.order.MoveToFront(element)
+	} else {
+		h.entries[served.revision] = h.order.PushFront(served)
+	}
+	h.bytes += len(served.encoded)
+
+	for h.bytes > h.maxBytes && h.order.Len() > 1 {
+		oldest := h.order.Back()
+		evicted := h.order.Remove(oldest).(servedConfig)
+		delete(h.entries, evicted.revision)
+		h.bytes -= len(evicted.encoded)
+	}
+}
+
+func (h *configHistory) find(revision string) (servedConfig, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	element, ok := h.entries[revision]
+	if !ok {
+		return servedConfig{}, false
+	}
+	h.order.MoveToFront(element)
+	return element.Value.(servedConfig), true
+}
+
+// writeConfig sends a finished config: 304 when the client's ETag is
+// current, a JSON Patch against its base revision when history still holds
+// it, or the whole document in the negotiated format, compressed when the
+// client accepts it
+func writeConfig(w http.ResponseWriter, r *http.Request, history *configHistory, revision string, encoded []byte, cache *compressedCache) {
+	base := r.Header.Get("X-Base-Revision")
+	if base == "" {
+		base = r.URL.Query().Get("base_revision")
+	}
+	previous, known := history.find(base)
+	history.remember(servedConfig{revision: revision, encoded: encoded})
+
+	w.Header().Set("X-Config-Revision", revision)
+	// The revision leaves clock readings out, so it's a weak validator.
+	// Each payload format is its own representation with its own tag.
+	format := negotiatePayloadFormat(r)
+	etag := `W/"` + revision + `"`
+	if format.name != jsonFormat.name {
+		etag = `W/"` + revision + "." + format.name + `"`
+	}
+	setCacheHeaders(w, etag, "private", renderCacheTTL, time.Now())
+	if notModified(w, r, etag) {
+		return
+	}
+	// JSON Patch only describes JSON documents
+	if known && format.name == jsonFormat.name {
+		var document interface{}
+		json.Unmarshal(encoded, &document)
+		patch := diffJSON(previous.document(), document, "", []PatchOperation{})
+		encodedPatch, _ := json.Marshal(patch)
+		// A patch bigger than the document saves nothing
+		if len(encodedPatch) < len(encoded) {
+			// A patch only makes sense against the client's own base, so
+			// it must never be stored or revalidated as the document
+			w.Header().Del("ETag")
+			w.Header().Set("Cache-Control", "no-store")
+			w.Header().Set("Content-Type", "application/json-patch+json")
+			w.Header().Set("X-Base-Revision", base)
+			writeCompressed(w, r, encodedPatch, nil, compressedKey{})
+			return
+		}
+	}
+
+	writePayload(w, r, format, encoded, cache, revision)
+}
+
+// ==================== JSON PATCH ====================
+
+// PatchOperation is one RFC 6902 operation
+type PatchOperation struct {
+	Op    string
+	Path  string
+	Value interface{}
+}
+
+// MarshalJSON leaves "value" off removes but keeps it, even when null,
+// everywhere else
+func (op PatchOperation) MarshalJSON() ([]byte, error) {
+	encoded := map[string]interface{}{"op": op.Op, "path": op.Path}
+	if op.Op != "remove" {
+		encoded["value"] = op.Value
+	}
+	return json.Marshal(encoded)
+}
+
+// diffJSON appends the operations turning from into to. Objects are
+// compared key by key and arrays index by index, with trailing elements
+// added or removed; anything else that differs is replaced.
+func diffJSON(from, to interface{}, path string, ops []PatchOperation) []PatchOperation {
+	switch a := from.(type) {
+	case map[string]interface{}:
+		b, ok := to.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(a)+len(b))
+		for key := range a {
+			keys = append(keys, key)
+		}
+		for key := range b {
+			if _, ok := a[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			childPath := path + "/" + escapePointer(key)
+			before, had := a[key]
+			after, has := b[key]
+			switch {
+			case !has:
+				ops = append(ops, PatchOperation{Op: "remove", Path: childPath})
+			case !had:
+				ops = append(ops, PatchOperation{Op: "add", Path: childPath, Value: after})
+			default:
+				ops = diffJSON(before, after, childPath, ops)
+			}
+		}
+		return ops
+	case []interface{}:
+		b, ok := to.([]interface{})
+		if !ok {
+			break
+		}
+		common := min(len(a), len(b))
+		for i := 0; i < common; i++ {
+			ops = diffJSON(a[i], b[i], path+"/"+strconv.Itoa(i), ops)
+		}
+		for i := common; i < len(b); i++ {
+			ops = append(ops, PatchOperation{Op: "add", Path: path + "/" + strconv.Itoa(i), Value: b[i]})
+		}
+		for i := len(a) - 1; i >= common; i-- {
+			ops = append(ops, PatchOperation{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+		}
+		return ops
+	}
+
+	if !reflect.DeepEqual(from, to) {
+		ops = append(ops, PatchOperation{Op: "replace", Path: path, Value: to})
+	}
+	return ops
+}
+
+// escapePointer escapes a key for use in a JSON Pointer (RFC 6901)
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// applyPatch applies RFC 6902 add/remove/replace operations to a generic
+// JSON document, the way a client would
+func applyPatch(t *testing.T, document interface{}, ops []PatchOperation) interface{} {
+	t.Helper()
+	for _, op := range ops {
+		var err error
+		document, err = applyOperation(document, splitPointer(op.Path), op)
+		if err != nil {
+			t.Fatalf("applying %s %s: %v", op.Op, op.Path, err)
+		}
+	}
+	return document
+}
+
+func splitPointer(path string) []string {
+	if path == "" {
+		return nil
+	}
+	tokens := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens
+}
+
+func applyOperation(node interface{}, tokens []string, op PatchOperation) (interface{}, error) {
+	if len(tokens) == 0 {
+		if op.Op == "remove" {
+			return nil, nil
+		}
+		return op.Value, nil
+	}
+	token, rest := tokens[0], tokens[1:]
+
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if len(rest) == 0 {
+			switch op.Op {
+			case "remove":
+				if _, ok := v[token]; !ok {
+					return nil, fmt.Errorf("remove of missing key %q", token)
+				}
+				delete(v, token)
+			case "replace":
+				if _, ok := v[token]; !ok {
+					return nil, fmt.Errorf("replace of missing key %q", token)
+				}
+				v[token] = op.Value
+			default:
+				v[token] = op.Value
+			}
+			return v, nil
+		}
+		child, err := applyOperation(v[token], rest, op)
+		v[token] = child
+		return v, err
+	case []interface{}:
+		index := len(v)
+		if token != "-" {
+			parsed, err := strconv.Atoi(token)
+			if err != nil {
+				return nil, err
+			}
+			index = parsed
+		}
+		if len(rest) == 0 {
+			switch op.Op {
+			case "add":
+				if index > len(v) {
+					return nil, fmt.Errorf("add past end at %d", index)
+				}
+				v = append(v[:index], append([]interface{}{op.Value}, v[index:]...)...)
+			case "remove":
+				if index >= len(v) {
+					return nil, fmt.Errorf("remove past end at %d", index)
+				}
+				v = append(v[:index], v[index+1:]...)
+			case "replace":
+				if index >= len(v) {
+					return nil, fmt.Errorf("replace past end at %d", index)
+				}
+				v[index] = op.Value
+			}
+			return v, nil
+		}
+		if index >= len(v) {
+			return nil, fmt.Errorf("index %d out of range", index)
+		}
+		child, err := applyOperation(v[index], rest, op)
+		v[index] = child
+		return v, err
+	}
+	return nil, fmt.Errorf("cannot descend into %T", node)
+}
+
+func decodeJSON(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("bad JSON %s: %v", s, err)
+	}
+	return v
+}
+
+func TestDiffJSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+	}{
+		{"identical", `{"a":1}`, `{"a":1}`},
+		{"replace scalar", `{"a":1}`, `{"a":2}`},
+		{"add key", `{"a":1}`, `{"a":1,"b":[1,2]}`},
+		{"remove key", `{"a":1,"b":2}`, `{"a":1}`},
+		{"null value kept", `{"a":1}`, `{"a":null}`},
+		{"type change", `{"a":{"b":1}}`, `{"a":[1]}`},
+		{"array grows", `[1,2]`, `[1,2,3,4]`},
+		{"array shrinks", `[1,2,3,4]`, `[1]`},
+		{"array to empty", `{"c":[1,2]}`, `{"c":[]}`},
+		{"nested component change", `{"components":[{"id":"x","props":{"text":"hi"}},{"id":"y"}]}`, `{"components":[{"id":"x","props":{"text":"salut","new":true}}]}`},
+		{"escaped keys", `{"a/b":1,"c~d":2}`, `{"a/b":3,"c~d":4,"e/f~g":5}`},
+		{"root replace", `1`, `"x"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to := decodeJSON(t, tt.from), decodeJSON(t, tt.to)
+			ops := diffJSON(decodeJSON(t, tt.from), to, "", []PatchOperation{})
+			got := applyPatch(t, from, ops)
+			if !reflect.DeepEqual(got, to) {
+				t.Errorf("patched = %v, want %v (ops %+v)", got, to, ops)
+			}
+			if tt.from == tt.to && len(ops) != 0 {
+				t.Errorf("identical documents produced %d ops", len(ops))
+			}
+		})
+	}
+}
+
+func TestPatchOperationJSON(t *testing.T) {
+	tests := []struct {
+		op   PatchOperation
+		want string
+	}{
+		{PatchOperation{Op: "remove", Path: "/a"}, `{"op":"remove","path":"/a"}`},
+		{PatchOperation{Op: "replace", Path: "/a", Value: nil}, `{"op":"replace","path":"/a","value":null}`},
+		{PatchOperation{Op: "add", Path: "/a~1b", Value: 1}, `{"op":"add","path":"/a~1b","value":1}`},
+	}
+	for _, tt := range tests {
+		encoded, _ := json.Marshal(tt.op)
+		if string(encoded) != tt.want {
+			t.Errorf("encoded %s, want %s", encoded, tt.want)
+		}
+	}
+}
+
+func TestConfigHistoryEvictsByBytes(t *testing.T) {
+	h := newConfigHistory(10)
+	h.remember(servedConfig{revision: "a", encoded: []byte("aaaa")})
+	h.remember(servedConfig{revision: "b", encoded: []byte("bbbb")})
+	h.find("a") // a is now the most recently used
+	h.remember(servedConfig{revision: "c", encoded: []byte("cccc")})
+
+	for revision, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok := h.find(revision); ok != want {
+			t.Errorf("find(%q) = %v, want %v", revision, ok, want)
+		}
+	}
+	if h.bytes != 8 {
+		t.Errorf("bytes = %d, want 8", h.bytes)
+	}
+
+	// A single oversized document is still kept
+	h.remember(servedConfig{revision: "big", encoded: make([]byte, 64)})
+	if _, ok := h.find("big"); !ok || h.order.Len() != 1 {
+		t.Errorf("oversized entry: found %v, %d entries", ok, h.order.Len())
+	}
+}
+
+// Each screen and mode fills only its own history, and the least recently
+// used history goes once there are too many
+func TestConfigHistoriesPerScreen(t *testing.T) {
+	h := newConfigHistories(10, 2)
+	h.forScreen("/", "day").remember(servedConfig{revision: "home", encoded: []byte("hhhh")})
+	cart := h.forScreen("/cart", "day")
+	for _, revision := range []string{"c1", "c2", "c3"} {
+		cart.remember(servedConfig{revision: revision, encoded: []byte("cccc")})
+	}
+
+	if _, ok := h.forScreen("/", "day").find("home"); !ok {
+		t.Error("cart revisions pushed out home's")
+	}
+	if _, ok := h.forScreen("/", "night").find("home"); ok {
+		t.Error("night found the day revision")
+	}
+	// "/", "night" was the third history, evicting the cart's
+	if _, ok := h.histories[configHistoryKey{screen: "/cart", mode: "day"}]; ok || h.order.Len() != 2 {
+		t.Errorf("holds %d histories, cart kept %v; want 2 without the cart", h.order.Len(), ok)
+	}
+}
+
+// stripClocks drops the fields that change every request
+func stripClocks(document interface{}) interface{} {
+	if config, ok := document.(map[string]interface{}); ok {
+		if metadata, ok := config["metadata"].(map[string]interface{}); ok {
+			for _, key := range []string{"timestamp", "server_time"} {
+				delete(metadata, key)
+			}
+		}
+		if components, ok := config["components"].([]interface{}); ok {
+			stripServerTimes(components)
+		}
+	}
+	return document
+}
+
+func getUiConfig(t *testing.T, target string, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	rec := httptest.NewRecorder()
+	withAuth(http.HandlerFunc(handleUiConfig)).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestUiConfigPatchResponse(t *testing.T) {
+	quietLogs(t)
+
+	base := getUiConfig(t, "/api/ui-config?screen=/product&id=prod_1&locale=en", nil)
+	revision := base.Header().Get("X-Config-Revision")
+	if base.Code != http.StatusOK || revision == "" {
+		t.Fatalf("base: status %d, revision %q", base.Code, revision)
+	}
+
+	target := getUiConfig(t, "/api/ui-config?screen=/product&id=prod_1&locale=fr", nil)
+	patched := getUiConfig(t, "/api/ui-config?screen=/product&id=prod_1&locale=fr", map[string]string{"X-Base-Revision": revision})
+	if ct := patched.Header().Get("Content-Type"); ct != "application/json-patch+json" {
+		t.Fatalf("Content-Type = %q, want a patch", ct)
+	}
+	if got := patched.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("patch Cache-Control = %q, want no-store", got)
+	}
+	if got := patched.Header().Get("ETag"); got != "" {
+		t.Errorf("patch carries ETag %q", got)
+	}
+	if got := patched.Header().Get("X-Base-Revision"); got != revision {
+		t.Errorf("X-Base-Revision = %q, want %q", got, revision)
+	}
+	if vary := patched.Header().Get("Vary"); !strings.Contains(vary, "X-Base-Revision") {
+		t.Errorf("Vary = %q, missing X-Base-Revision", vary)
+	}
+
+	var ops []PatchOperation
+	var raw []map[string]interface{}
+	if err := json.Unmarshal(patched.Body.Bytes(), &raw); err != nil {
+		t.Fatal(err)
+	}
+	for _, op := range raw {
+		ops = append(ops, PatchOperation{Op: op["op"].(string), Path: op["path"].(string), Value: op["value"]})
+	}
+	got := stripClocks(applyPatch(t, decodeJSON(t, base.Body.String()), ops))
+	want := stripClocks(decodeJSON(t, target.Body.String()))
+	if !reflect.DeepEqual(got, want) {
+		t.Error("base + patch differs from the full document")
+	}
+
+	// Full documents keep their validator
+	if target.Header().Get("ETag") == "" || target.Header().Get("Cache-Control") == "no-store" {
+		t.Errorf("full document headers: ETag %q, Cache-Control %q", target.Header().Get("ETag"), target.Header().Get("Cache-Control"))
+	}
+}
+
+func TestUiConfigUnknownBaseSendsDocument(t *testing.T) {
+	quietLogs(t)
+	rec := getUiConfig(t, "/api/ui-config?screen=/cart", map[string]string{"X-Base-Revision": "cfg_unknown"})
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want the full document", ct)
+	}
+}
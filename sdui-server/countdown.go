@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// ==================== COUNTDOWNS ====================
+
+// Countdown timers are authored with an "ends_with" prop naming the mode
+// (campaign) window they count down to, defaulting to the mode being
+// rendered. Per request the server replaces it with the window's absolute
+// end and its own clock, so clients can correct for skew:
+//
+//	"end_time":    "2025-06-01T14:00:00+02:00"
+//	"server_time": "2025-06-01T13:12:37+02:00"
+//
+// Timers whose window isn't running any more are dropped.
+func resolveCountdowns(components []interface{}, ctx RenderContext) []interface{} {
+	resolved := []interface{}{}
+	for _, c := range components {
+		component, ok := c.(map[string]interface{})
+		if !ok {
+			resolved = append(resolved, c)
+			continue
+		}
+
+		if component["type"] == "countdown_timer" {
+			props, _ := component["props"].(map[string]interface{})
+			if props == nil {
+				props = map[string]interface{}{}
+				component["props"] = props
+			}
+			mode, _ := props["ends_with"].(string)
+			if mode == "" {
+				mode = ctx.Mode
+			}
+			end, ok := scheduledWindowEnd(mode, ctx.Now)
+			if !ok {
+				continue
+			}
+			delete(props, "ends_with")
+			props["end_time"] = end.Format(time.RFC3339)
+			props["server_time"] = ctx.Now.Format(time.RFC3339)
+		}
+
+		if children, ok := component["children"].([]interface{}); ok {
+			component["children"] = resolveCountdowns(children, ctx)
+		}
+		resolved = append(resolved, component)
+	}
+	return resolved
+}
+
+// scheduledWindowEnd returns when the mode's window running at now ends,
+// or false when the mode isn't on the schedule right now
+func scheduledWindowEnd(mode string, now time.Time) (time.Time, bool) {
+	if modeForHour(now.Hour()) != mode {
+		return time.Time{}, false
+	}
+	_, end := currentModeWindow(now)
+	return end, true
+}
+
+// ==================== LIVE COUNTDOWNS ====================
+
+// The scheduler keeps countdowns on live clients honest: while a window
+// runs it re-sends each timer's end with the server clock, and when the
+// window closes it pushes an end_time of now so timers stop at zero
+// instead of waiting for the next layout fetch.
+
+const countdownResyncInterval = time.Minute
+
+// countdownScreens are the screens whose layouts carry countdown timers
+var countdownScreens = []string{"/"}
+
+// publishCountdowns sends a patch for every countdown on mode's layouts
+// and returns how many it sent
+func publishCountdowns(mode string, now time.Time) int {
+	published := 0
+	for _, screen := range countdownScreens {
+		config := buildScreenConfig(screen, mode, RenderContext{Screen: screen, Mode: mode, Now: now})
+		components, _ := config["components"].([]interface{})
+		for _, countdown := range findCountdowns(components) {
+			id, _ := countdown["id"].(string)
+			if id == "" {
+				continue
+			}
+			props, _ := countdown["props"].(map[string]interface{})
+			endsWith, _ := props["ends_with"].(string)
+			if endsWith == "" {
+				endsWith = mode
+			}
+			end, running := scheduledWindowEnd(endsWith, now)
+			if !running {
+				end = now
+			}
+			liveUpdates.publish(LiveUpdate{
+				Screen:      screen,
+				ComponentID: id,
+				Props: map[string]interface{}{
+					"end_time":    end.Format(time.RFC3339),
+					"server_time": now.Format(time.RFC3339),
+				},
+			})
+			published++
+		}
+	}
+	return published
+}
+
+// findCountdowns collects countdown components, including nested ones
+func findCountdowns(components []interface{}) []map[string]interface{} {
+	var found []map[string]interface{}
+	for _, c := range components {
+		component, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if component["type"] == "countdown_timer" {
+			found = append(found, component)
+		}
+		if children, ok := component["children"].([]interface{}); ok {
+			found = append(found, findCountdowns(children)...)
+		}
+	}
+	return found
+}
+
+// watchCountdowns re-sends running countdowns to live clients every
+// countdownResyncInterval
+func watchCountdowns(ctx context.Context) {
+	ticker := time.NewTicker(countdownResyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			publishCountdowns(getCurrentMode(), now)
+		}
+	}
+}
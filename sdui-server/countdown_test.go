@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestResolveCountdowns(t *testing.T) {
+	lunch := time.Date(2025, 6, 1, 12, 30, 0, 0, time.Local)
+	timer := func(endsWith string) map[string]interface{} {
+		props := map[string]interface{}{}
+		if endsWith != "" {
+			props["ends_with"] = endsWith
+		}
+		return map[string]interface{}{"id": "timer", "type": "countdown_timer", "props": props}
+	}
+
+	tests := []struct {
+		name       string
+		components []interface{}
+		mode       string
+		want       string
+	}{
+		{"running window resolves", []interface{}{timer("")}, "flash_sale",
+			`[{"id":"timer","props":{"end_time":"` + time.Date(2025, 6, 1, 14, 0, 0, 0, time.Local).Format(time.RFC3339) + `","server_time":"` + lunch.Format(time.RFC3339) + `"},"type":"countdown_timer"}]`},
+		{"closed window is dropped", []interface{}{timer("evening"), map[string]interface{}{"id": "keep"}}, "flash_sale", `[{"id":"keep"}]`},
+		{"everything dropped stays a list", []interface{}{timer("evening")}, "flash_sale", `[]`},
+		{"no components", nil, "flash_sale", `[]`},
+		{"nested timers", []interface{}{map[string]interface{}{"id": "row", "children": []interface{}{timer("morning")}}}, "flash_sale", `[{"children":[],"id":"row"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved := resolveCountdowns(tt.components, RenderContext{Mode: tt.mode, Now: lunch})
+			encoded, _ := json.Marshal(resolved)
+			if string(encoded) != tt.want {
+				t.Errorf("got %s, want %s", encoded, tt.want)
+			}
+		})
+	}
+}
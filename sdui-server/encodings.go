@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// ==================== PAYLOAD ENCODINGS ====================
+
+// A ui-config can be sent as JSON, MessagePack or CBOR, picked from the
+// Accept header. The binary forms are transcoded from the JSON document,
+// so they decode to exactly the values a JSON parser would produce:
+// integral numbers as integers, everything else as doubles.
+
+type payloadFormat struct {
+	name        string
+	contentType string
+	aliases     []string
+	encode      func(value interface{}) ([]byte, error)
+}
+
+var cborEncoder, _ = cbor.CoreDetEncOptions().EncMode()
+
+var jsonFormat = payloadFormat{name: "json", contentType: "application/json"}
+
+// payloadFormats in order of preference when a client rates them equally
+var payloadFormats = []payloadFormat{
+	jsonFormat,
+	{
+		name:        "msgpack",
+		contentType: "application/msgpack",
+		aliases:     []string{"application/x-msgpack", "application/vnd.msgpack"},
+		encode:      encodeMsgpack,
+	},
+	{
+		name:        "cbor",
+		contentType: "application/cbor",
+		encode:      cborEncoder.Marshal,
+	},
+}
+
+// negotiatePayloadFormat picks a format from the Accept header, falling
+// back to JSON
+func negotiatePayloadFormat(r *http.Request) payloadFormat {
+	header := r.Header.Get("Accept")
+	if header == "" {
+		return jsonFormat
+	}
+
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		weight := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if q, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+					weight = parsed
+				}
+			}
+		}
+		weights[strings.ToLower(strings.TrimSpace(mediaType))] = weight
+	}
+
+	best, bestWeight := jsonFormat, 0.0
+	for _, format := range payloadFormats {
+		for _, mediaType := range append([]string{format.contentType}, format.aliases...) {
+			if weight, ok := weights[mediaType]; ok && weight > bestWeight {
+				best, bestWeight = format, weight
+			}
+		}
+	}
+	return best
+}
+
+// transcode re-encodes a JSON document in format
+func (format payloadFormat) transcode(encoded []byte) ([]byte, error) {
+	if format.encode == nil {
+		return encoded, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	return format.encode(jsonNumbers(document))
+}
+
+// jsonNumbers replaces json.Numbers with the int64 or float64 a JSON
+// parser would read them as
+func jsonNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if !strings.ContainsAny(v.String(), ".eE") {
+			if n, err := v.Int64(); err == nil {
+				return n
+			}
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, child := range v {
+			v[key] = jsonNumbers(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = jsonNumbers(child)
+		}
+	}
+	return value
+}
+
+func encodeMsgpack(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := msgpack.NewEncoder(&buf)
+	encoder.SetSortMapKeys(true)
+	encoder.UseCompactInts(true)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writePayload sends a JSON document in the negotiated format, compressing
+// it through cache under revision
+func writePayload(w http.ResponseWriter, r *http.Request, format payloadFormat, encoded []byte, cache *compressedCache, revision string) {
+	body, err := format.transcode(encoded)
+	if err != nil {
+		log.Printf("❌ Encoding %s payload: %v", format.name, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", format.contentType)
+	writeCompressed(w, r, body, cache, compressedKey{format: format.name, revision: revision})
+}
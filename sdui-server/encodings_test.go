@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestNegotiatePayloadFormat(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", "json"},
+		{"*/*", "json"},
+		{"application/json", "json"},
+		{"application/msgpack", "msgpack"},
+		{"application/x-msgpack", "msgpack"},
+		{"application/vnd.msgpack", "msgpack"},
+		{"application/cbor", "cbor"},
+		{"APPLICATION/CBOR", "cbor"},
+		{"application/json, application/cbor", "json"},
+		{"application/json;q=0.5, application/cbor", "cbor"},
+		{"application/cbor;q=0.9, application/msgpack;q=0.8", "cbor"},
+		{"application/cbor; charset=binary; q=0.3, application/json;q=0.2", "cbor"},
+		{"application/cbor;q=0", "json"},
+		{"text/html", "json"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		if got := negotiatePayloadFormat(req).name; got != tt.want {
+			t.Errorf("Accept %q: format %s, want %s", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func payloadFormatNamed(t *testing.T, name string) payloadFormat {
+	t.Helper()
+	for _, format := range payloadFormats {
+		if format.name == name {
+			return format
+		}
+	}
+	t.Fatalf("no %s format", name)
+	return payloadFormat{}
+}
+
+// decodePayload reads a transcoded body back into generic values
+func decodePayload(t *testing.T, format string, body []byte) interface{} {
+	t.Helper()
+	var document interface{}
+	var err error
+	switch format {
+	case "msgpack":
+		err = msgpack.Unmarshal(body, &document)
+	case "cbor":
+		err = cbor.Unmarshal(body, &document)
+	}
+	if err != nil {
+		t.Fatalf("decoding %s: %v", format, err)
+	}
+	return document
+}
+
+// asJSONValues maps decoded binary values onto what encoding/json produces:
+// string-keyed objects and float64 numbers
+func asJSONValues(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			v[key] = asJSONValues(child)
+		}
+		return v
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			converted[fmt.Sprint(key)] = asJSONValues(child)
+		}
+		return converted
+	case []interface{}:
+		for i, child := range v {
+			v[i] = asJSONValues(child)
+		}
+		return v
+	case int8, int16, int32, int64, uint8, uint16, uint32, uint64:
+		var f float64
+		fmt.Sscan(fmt.Sprint(v), &f)
+		return f
+	case float32:
+		return float64(v)
+	}
+	return value
+}
+
+func TestTranscodeMatchesJSON(t *testing.T) {
+	documents := []string{
+		`{}`,
+		`{"a":1,"b":-2,"c":1.5,"d":1.0,"e":1e3,"f":9007199254740993}`,
+		`{"s":"héllo","t":true,"n":null,"l":[1,"x",{"y":[]}]}`,
+		`{"nested":{"deep":{"list":[0.1,0.2,3]}}}`,
+		`[1,2,3]`,
+	}
+	for _, name := range []string{"msgpack", "cbor"} {
+		format := payloadFormatNamed(t, name)
+		for _, document := range documents {
+			body, err := format.transcode([]byte(document))
+			if err != nil {
+				t.Fatalf("%s %s: %v", name, document, err)
+			}
+			got := asJSONValues(decodePayload(t, name, body))
+			if want := decodeJSON(t, document); !reflect.DeepEqual(got, want) {
+				t.Errorf("%s %s decoded to %v, want %v", name, document, got, want)
+			}
+		}
+	}
+}
+
+// Integral JSON numbers travel as integers and the rest as doubles, so
+// binary clients see the same number types a JSON parser would
+func TestTranscodeNumberTypes(t *testing.T) {
+	document := []byte(`{"int":3,"big":9007199254740993,"float":1.5,"whole_float":2.0,"exp":1e2}`)
+	wantInteger := map[string]bool{"int": true, "big": true, "float": false, "whole_float": false, "exp": false}
+
+	for _, name := range []string{"msgpack", "cbor"} {
+		body, err := payloadFormatNamed(t, name).transcode(document)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded := asStringKeys(decodePayload(t, name, body))
+		for key, integer := range wantInteger {
+			_, isFloat := decoded[key].(float64)
+			if isFloat == integer {
+				t.Errorf("%s %s decoded as %T, want integer %v", name, key, decoded[key], integer)
+			}
+		}
+		if big := fmt.Sprint(decoded["big"]); big != "9007199254740993" {
+			t.Errorf("%s big = %s, lost precision", name, big)
+		}
+	}
+}
+
+func asStringKeys(value interface{}) map[string]interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			converted[fmt.Sprint(key)] = child
+		}
+		return converted
+	}
+	return nil
+}
+
+func TestUiConfigFormatsMatchJSON(t *testing.T) {
+	quietLogs(t)
+	target := "/api/ui-config?screen=/product&id=prod_1&locale=en"
+	want := stripClocks(decodeJSON(t, getUiConfig(t, target, nil).Body.String()))
+
+	for _, tt := range []struct{ format, accept string }{
+		{"msgpack", "application/msgpack"},
+		{"cbor", "application/cbor"},
+	} {
+		t.Run(tt.format, func(t *testing.T) {
+			rec := getUiConfig(t, target, map[string]string{"Accept": tt.accept})
+			if ct := rec.Header().Get("Content-Type"); ct != tt.accept {
+				t.Fatalf("Content-Type = %q, want %q", ct, tt.accept)
+			}
+			got := stripClocks(asJSONValues(decodePayload(t, tt.format, rec.Body.Bytes())))
+			if !reflect.DeepEqual(got, want) {
+				t.Error("binary config differs from the JSON one")
+			}
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ==================== EXPERIMENT MODEL ====================
+
+// Experiment splits traffic for one screen (and optionally one mode)
+// between layout variants
+type Experiment struct {
+	ID       string    `json:"id"`
+	Screen   string    `json:"screen"`
+	Mode     string    `json:"mode,omitempty"`     // empty = every mode
+	Segments []string  `json:"segments,omitempty"` // empty = every user
+	Active   bool      `json:"active"`
+	Variants []Variant `json:"variants"`
+}
+
+// Variant is one arm of an experiment. Weight is its share of traffic in
+// percent; the weights of an experiment add up to 100.
+type Variant struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"`
+
+	// Theme keys overriding the screen's theme
+	Theme map[string]interface{} `json:"theme,omitempty"`
+	// Per-component props/style patches keyed by component id
+	Patches map[string]ComponentPatch `json:"patches,omitempty"`
+	// Components replaces the whole component list when set
+	Components func() []interface{} `json:"-"`
+}
+
+type ComponentPatch struct {
+	Props map[string]interface{} `json:"props,omitempty"`
+	Style map[string]interface{} `json:"style,omitempty"`
+}
+
+// ==================== EXPERIMENT REGISTRY ====================
+
+// experiments is the validated registry; a bad definition stops startup
+// rather than silently skewing traffic
+var experiments = loadExperiments(getExperiments())
+
+func loadExperiments(defined []Experiment) []Experiment {
+	for _, experiment := range defined {
+		if err := experiment.validate(); err != nil {
+			log.Fatalf("❌ Invalid experiment %s: %v", experiment.ID, err)
+		}
+	}
+	return defined
+}
+
+// validate checks that the variants split all traffic between them
+func (e Experiment) validate() error {
+	if len(e.Variants) == 0 {
+		return fmt.Errorf("no variants")
+	}
+	total := 0
+	for _, variant := range e.Variants {
+		if variant.Weight < 0 {
+			return fmt.Errorf("variant %s has negative weight %d", variant.Name, variant.Weight)
+		}
+		total += variant.Weight
+	}
+	if total != 100 {
+		return fmt.Errorf("variant weights add up to %d, want 100", total)
+	}
+	return nil
+}
+
+func getExperiments() []Experiment {
+	return []Experiment{
+		{
+			ID:     "flash-grid-density",
+			Screen: "home",
+			Mode:   "flash_sale",
+			Active: true,
+			Variants: []Variant{
+				{Name: "control", Weight: 50},
+				{
+					Name:   "three-column",
+					Weight: 50,
+					Patches: map[string]ComponentPatch{
+						"flash-products": {
+							Props: map[string]interface{}{"columns": 3, "aspectRatio": 0.6},
+							Style: map[string]interface{}{"imageHeight": 100.0, "titleSize": 12.0},
+						},
+					},
+				},
+			},
+		},
+		{
+			ID:     "evening-hero",
+			Screen: "home",
+			Mode:   "evening",
+			Active: true,
+			Variants: []Variant{
+				{Name: "control", Weight: 70},
+				{
+					Name:   "static-banner",
+					Weight: 30,
+					Patches: map[string]ComponentPatch{
+						"evening-banner": {
+							Props: map[string]interface{}{"duration": 0, "title": msg("evening.banner.variant_title")},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// normalizeScreen maps route aliases onto the screen name experiments use
+func normalizeScreen(screen string) string {
+	switch screen {
+	case "", "/", "home":
+		return "home"
+	}
+	return strings.TrimPrefix(screen, "/")
+}
+
+// bucket deterministically maps a user onto 0-99 for an experiment
+func bucket(experimentID, userID string) int {
+	sum := sha256.Sum256([]byte(experimentID + ":" + userID))
+	return int(binary.BigEndian.Uint64(sum[:8]) % 100)
+}
+
+// assignVariant picks the user's variant by walking the cumulative weights
+func (e Experiment) assignVariant(userID string) Variant {
+	b := bucket(e.ID, userID)
+	cumulative := 0
+	for _, variant := range e.Variants {
+		cumulative += variant.Weight
+		if b < cumulative {
+			return variant
+		}
+	}
+	return e.Variants[0]
+}
+
+func (e Experiment) appliesTo(screen, mode string, userSegments []string) bool {
+	return e.Active && len(e.Variants) > 0 &&
+		e.Screen == normalizeScreen(screen) &&
+		(e.Mode == "" || e.Mode == mode) &&
+		(len(e.Segments) == 0 || inAnySegment(userSegments, e.Segments))
+}
+
+// experimentAssignments returns experiment id -> variant name for every
+// experiment running on the screen. Anonymous users aren't bucketed.
+func experimentAssignments(screen, mode, userID string) map[string]string {
+	assignments := map[string]string{}
+	if userID == "" {
+		return assignments
+	}
+	userSegments := segments.forUser(userID)
+	for _, experiment := range experiments {
+		if experiment.appliesTo(screen, mode, userSegments) {
+			assignments[experiment.ID] = experiment.assignVariant(userID).Name
+		}
+	}
+	return assignments
+}
+
+// formatAssignments flattens assignments into "exp:variant,exp:variant"
+func formatAssignments(assignments map[string]string) string {
+	parts := make([]string, 0, len(assignments))
+	for id, variant := range assignments {
+		parts = append(parts, id+":"+variant)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+// ==================== APPLYING VARIANTS ====================
+
+// applyExperiments rewrites a freshly built config with the user's variants
+// and records the assignments in metadata
+func applyExperiments(config map[string]interface{}, screen, mode, userID string) map[string]string {
+	assignments := map[string]string{}
+	if userID == "" {
+		return assignments
+	}
+
+	userSegments := segments.forUser(userID)
+	for _, experiment := range experiments {
+		if !experiment.appliesTo(screen, mode, userSegments) {
+			continue
+		}
+		variant := experiment.assignVariant(userID)
+		applyVariant(config, variant)
+		assignments[experiment.ID] = variant.Name
+	}
+	if len(assignments) > 0 {
+		if metadata, ok := config["metadata"].(map[string]interface{}); ok {
+			metadata["experiments"] = assignments
+		}
+	}
+	return assignments
+}
+
+// logExposure records that a user was shown their experiment variants
+func logExposure(assignments map[string]string, screen, mode, userID string) {
+	if len(assignments) == 0 {
+		return
+	}
+	analytics.Enqueue(AnalyticsEvent{
+		ID:   "evt_" + randomID(8),
+		Type: "experiment_exposure",
+		Data: map[string]interface{}{
+			"experiments": assignments,
+		},
+		ServerTime: time.Now().UTC(),
+		Mode:       mode,
+		UserID:     userID,
+		Screen:     screen,
+		Variant:    formatAssignments(assignments),
+	})
+}
+
+func applyVariant(config map[string]interface{}, variant Variant) {
+	if theme, ok := config["theme"].(map[string]interface{}); ok {
+		for key, value := range variant.Theme {
+			theme[key] = value
+		}
+	}
+	if variant.Components != nil {
+		config["components"] = variant.Components()
+	}
+	if len(variant.Patches) > 0 {
+		components, _ := config["components"].([]interface{})
+		patchComponents(components, variant.Patches)
+	}
+}
+
+func patchComponents(components []interface{}, patches map[string]ComponentPatch) {
+	for _, c := range components {
+		component, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if id, _ := component["id"].(string); id != "" {
+			if patch, ok := patches[id]; ok {
+				mergeInto(component, "props", patch.Props)
+				mergeInto(component, "style", patch.Style)
+			}
+		}
+		if children, ok := component["children"].([]interface{}); ok {
+			patchComponents(children, patches)
+		}
+	}
+}
+
+// mergeInto shallow-merges values into component[key], creating the map if needed
+func mergeInto(component map[string]interface{}, key string, values map[string]interface{}) {
+	if len(values) == 0 {
+		return
+	}
+	target, ok := component[key].(map[string]interface{})
+	if !ok {
+		target = map[string]interface{}{}
+		component[key] = target
+	}
+	for k, v := range values {
+		target[k] = v
+	}
+}
+
+// ==================== HANDLER ====================
+
+// handleExperiments lists experiments, or the caller's own assignments
+// with ?screen=&mode=
+func handleExperiments(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if screen := r.URL.Query().Get("screen"); screen != "" {
+		mode := r.URL.Query().Get("mode")
+		if mode == "" {
+			mode = getCurrentMode()
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"screen":      screen,
+			"mode":        mode,
+			"assignments": experimentAssignments(screen, mode, currentUserID(r)),
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"experiments": experiments,
+	})
+}
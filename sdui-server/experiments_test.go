@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestExperimentValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []int
+		wantErr bool
+	}{
+		{"even split", []int{50, 50}, false},
+		{"single arm", []int{100}, false},
+		{"three arms", []int{34, 33, 33}, false},
+		{"zero-weight arm", []int{100, 0}, false},
+		{"under 100", []int{50, 40}, true},
+		{"over 100", []int{70, 70}, true},
+		{"negative weight", []int{120, -20}, true},
+		{"no variants", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			experiment := Experiment{ID: "test"}
+			for i, weight := range tt.weights {
+				experiment.Variants = append(experiment.Variants, Variant{Name: fmt.Sprint("v", i), Weight: weight})
+			}
+			if err := experiment.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegisteredExperimentsValid(t *testing.T) {
+	for _, experiment := range getExperiments() {
+		if err := experiment.validate(); err != nil {
+			t.Errorf("%s: %v", experiment.ID, err)
+		}
+	}
+}
+
+func TestAssignVariant(t *testing.T) {
+	experiment := Experiment{ID: "split", Variants: []Variant{{Name: "control", Weight: 70}, {Name: "treatment", Weight: 30}}}
+
+	counts := map[string]int{}
+	for i := 0; i < 10_000; i++ {
+		userID := fmt.Sprint("user_", i)
+		variant := experiment.assignVariant(userID)
+		if again := experiment.assignVariant(userID); again.Name != variant.Name {
+			t.Fatalf("%s bucketed into %s then %s", userID, variant.Name, again.Name)
+		}
+		counts[variant.Name]++
+	}
+	if share := counts["treatment"]; share < 2700 || share > 3300 {
+		t.Errorf("treatment got %d of 10000 users, want about 3000", share)
+	}
+}
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ==================== FLAG MODEL ====================
+
+// Flag is a boolean or multivariate switch evaluated per request. Rules are
+// checked in order; the first matching rule's value wins, else Default.
+type Flag struct {
+	Key         string        `json:"key"`
+	Description string        `json:"description,omitempty"`
+	Type        string        `json:"type"` // "boolean" or "multivariate"
+	Enabled     bool          `json:"enabled"`
+	Default     interface{}   `json:"default"`
+	Variations  []interface{} `json:"variations,omitempty"`
+	Rules       []FlagRule    `json:"rules,omitempty"`
+}
+
+// FlagRule matches when every condition it sets holds
+type FlagRule struct {
+	UserIDs       []string    `json:"user_ids,omitempty"`
+	Percentage    *int        `json:"percentage,omitempty"` // rollout 0-100, bucketed by user id
+	Platforms     []string    `json:"platforms,omitempty"`
+	MinAppVersion string      `json:"min_app_version,omitempty"`
+	Modes         []string    `json:"modes,omitempty"`
+	Value         interface{} `json:"value"`
+}
+
+// FlagContext is everything targeting rules can look at
+type FlagContext struct {
+	UserID     string `json:"user_id,omitempty"`
+	Platform   string `json:"platform,omitempty"`
+	AppVersion string `json:"app_version,omitempty"`
+	Mode       string `json:"mode"`
+}
+
+// flagContextFromRequest reads platform and app version from the
+// X-Platform / X-App-Version headers, or platform / app_version params
+func flagContextFromRequest(r *http.Request, mode string) FlagContext {
+	ctx := FlagContext{
+		UserID:     currentUserID(r),
+		Platform:   r.Header.Get("X-Platform"),
+		AppVersion: r.Header.Get("X-App-Version"),
+		Mode:       mode,
+	}
+	if ctx.Platform == "" {
+		ctx.Platform = r.URL.Query().Get("platform")
+	}
+	if ctx.AppVersion == "" {
+		ctx.AppVersion = r.URL.Query().Get("app_version")
+	}
+	ctx.Platform = strings.ToLower(ctx.Platform)
+	return ctx
+}
+
+func (rule FlagRule) matches(flagKey string, ctx FlagContext) bool {
+	if len(rule.UserIDs) > 0 && !slices.Contains(rule.UserIDs, ctx.UserID) {
+		return false
+	}
+	if len(rule.Platforms) > 0 && !slices.Contains(rule.Platforms, ctx.Platform) {
+		return false
+	}
+	if len(rule.Modes) > 0 && !slices.Contains(rule.Modes, ctx.Mode) {
+		return false
+	}
+	if rule.MinAppVersion != "" && (ctx.AppVersion == "" || compareVersions(ctx.AppVersion, rule.MinAppVersion) < 0) {
+		return false
+	}
+	if rule.Percentage != nil {
+		if ctx.UserID == "" || bucket("flag:"+flagKey, ctx.UserID) >= *rule.Percentage {
+			return false
+		}
+	}
+	return true
+}
+
+// Evaluate returns the flag's value for a context
+func (f Flag) Evaluate(ctx FlagContext) interface{} {
+	if !f.Enabled {
+		return f.off()
+	}
+	for _, rule := range f.Rules {
+		if rule.matches(f.Key, ctx) {
+			return rule.Value
+		}
+	}
+	return f.Default
+}
+
+// off is the value of a disabled flag: false, or the first variation
+func (f Flag) off() interface{} {
+	if f.Type == "boolean" {
+		return false
+	}
+	if len(f.Variations) > 0 {
+		return f.Variations[0]
+	}
+	return f.Default
+}
+
+func (f Flag) validate() error {
+	if f.Key == "" {
+		return fmt.Errorf("flag key is required")
+	}
+	switch f.Type {
+	case "boolean":
+		if _, ok := f.Default.(bool); !ok {
+			return fmt.Errorf("flag %s: boolean default must be true or false", f.Key)
+		}
+		for _, rule := range f.Rules {
+			if _, ok := rule.Value.(bool); !ok {
+				return fmt.Errorf("flag %s: boolean rule value must be true or false", f.Key)
+			}
+		}
+	case "multivariate":
+		if len(f.Variations) == 0 {
+			return fmt.Errorf("flag %s: multivariate flags need variations", f.Key)
+		}
+		if !containsValue(f.Variations, f.Default) {
+			return fmt.Errorf("flag %s: default %v is not a variation", f.Key, f.Default)
+		}
+		for _, rule := range f.Rules {
+			if !containsValue(f.Variations, rule.Value) {
+				return fmt.Errorf("flag %s: rule value %v is not a variation", f.Key, rule.Value)
+			}
+		}
+	default:
+		return fmt.Errorf("flag %s: unknown type %q", f.Key, f.Type)
+	}
+	for _, rule := range f.Rules {
+		if rule.Percentage != nil && (*rule.Percentage < 0 || *rule.Percentage > 100) {
+			return fmt.Errorf("flag %s: percentage must be between 0 and 100", f.Key)
+		}
+	}
+	return nil
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareVersions compares dotted numeric versions ("1.10.0" > "1.9")
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(strings.TrimSpace(as[i]))
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(strings.TrimSpace(bs[i]))
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// ==================== FLAG STORE ====================
+
+type flagStore struct {
+	mu    sync.RWMutex
+	flags map[string]Flag
+}
+
+var flags = newFlagStore(defaultFlags())
+
+func newFlagStore(seed []Flag) *flagStore {
+	s := &flagStore{flags: make(map[string]Flag)}
+	for _, flag := range seed {
+		s.flags[flag.Key] = flag
+	}
+	return s
+}
+
+func (s *flagStore) get(key string) (Flag, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	flag, ok := s.flags[key]
+	return flag, ok
+}
+
+func (s *flagStore) all() []Flag {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]Flag, 0, len(s.flags))
+	for _, flag := range s.flags {
+		list = append(list, flag)
+	}
+	slices.SortFunc(list, func(a, b Flag) int { return strings.Compare(a.Key, b.Key) })
+	return list
+}
+
+func (s *flagStore) put(flag Flag) error {
+	if err := flag.validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flags[flag.Key] = flag
+	return nil
+}
+
+func (s *flagStore) delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.flags, key)
+}
+
+// evaluateAll resolves every flag for a context
+func (s *flagStore) evaluateAll(ctx FlagContext) map[string]interface{} {
+	values := map[string]interface{}{}
+	for _, flag := range s.all() {
+		values[flag.Key] = flag.Evaluate(ctx)
+	}
+	return values
+}
+
+// value evaluates a single flag, reporting false for unknown keys
+func (s *flagStore) value(key string, ctx FlagContext) (interface{}, bool) {
+	flag, ok := s.get(key)
+	if !ok {
+		return nil, false
+	}
+	return flag.Evaluate(ctx), true
+}
+
+func defaultFlags() []Flag {
+	fifty := 50
+	return []Flag{
+		{
+			Key:         "morning_stories",
+			Description: "Story circles under the morning search bar",
+			Type:        "boolean",
+			Enabled:     true,
+			Default:     true,
+		},
+		{
+			Key:         "testimonials",
+			Description: "Customer testimonial cards on home",
+			Type:        "boolean",
+			Enabled:     true,
+			Default:     false,
+			Rules: []FlagRule{
+				{Modes: []string{"morning", "day"}, Value: true},
+			},
+		},
+		{
+			Key:         "flash_promo_badges",
+			Description: "Promo badge row on the flash sale layout",
+			Type:        "boolean",
+			Enabled:     true,
+			Default:     true,
+			Rules: []FlagRule{
+				{Platforms: []string{"web"}, Value: false},
+			},
+		},
+		{
+			Key:         "checkout_button_style",
+			Description: "Checkout button variant on the cart screen",
+			Type:        "multivariate",
+			Enabled:     true,
+			Default:     "classic",
+			Variations:  []interface{}{"classic", "sticky", "express"},
+			Rules: []FlagRule{
+				{MinAppVersion: "2.0.0", Percentage: &fifty, Value: "express"},
+			},
+		},
+	}
+}
+
+// ==================== LAYOUT CONDITIONS ====================
+
+// flagConditionHolds evaluates a component's flag condition: either a flag
+// key (true when the flag is true) or {"key": ..., "value": ...} (true when
+// the flag equals value). See applyVisibility.
+func flagConditionHolds(condition interface{}, ctx FlagContext) bool {
+	switch cond := condition.(type) {
+	case string:
+		value, ok := flags.value(cond, ctx)
+		return ok && value == true
+	case map[string]interface{}:
+		key, _ := cond["key"].(string)
+		value, ok := flags.value(key, ctx)
+		if !ok {
+			return false
+		}
+		expected, hasExpected := cond["value"]
+		if !hasExpected {
+			return value == true
+		}
+		return reflect.DeepEqual(normalizeJSON(value), normalizeJSON(expected))
+	}
+	return false
+}
+
+// normalizeJSON round-trips a value so Go ints and JSON float64s compare equal
+func normalizeJSON(v interface{}) interface{} {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	json.Unmarshal(encoded, &out)
+	return out
+}
+
+// ==================== HANDLERS ====================
+
+// handleFlags returns every flag evaluated for the caller
+func handleFlags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := flagContextFromRequest(r, getCurrentMode())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"flags":   flags.evaluateAll(ctx),
+		"context": ctx,
+	})
+}
+
+// handleFlagAdmin lists, creates/updates (PUT) or deletes flag definitions
+// at /api/admin/flags[/<key>]
+func handleFlagAdmin(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/api/admin/flags"), "/")
+
+	switch r.Method {
+	case http.MethodGet:
+		if key == "" {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{"flags": flags.all()})
+			return
+		}
+		flag, ok := flags.get(key)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(flag)
+	case http.MethodPut:
+		var flag Flag
+		if err := json.NewDecoder(r.Body).Decode(&flag); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if key != "" {
+			flag.Key = key
+		}
+		if err := flags.put(flag); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		publishFlagChange(flag.Key, "flag_updated")
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(flag)
+	case http.MethodDelete:
+		flags.delete(key)
+		publishFlagChange(key, "flag_deleted")
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// publishFlagChange drops cached renders and tells open UI streams a flag
+// their layouts may be gated on has changed
+func publishFlagChange(key, reason string) {
+	renderCache.invalidate(reason, nil)
+	uiChanges.publish(uiChange{
+		Type: "layout",
+		Data: map[string]interface{}{
+			"reason": reason,
+			"flag":   key,
+		},
+	})
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFlagEvaluate(t *testing.T) {
+	all, none := 100, 0
+	flag := Flag{
+		Key:        "checkout_style",
+		Type:       "multivariate",
+		Enabled:    true,
+		Default:    "classic",
+		Variations: []interface{}{"classic", "sticky", "express", "beta"},
+		Rules: []FlagRule{
+			{UserIDs: []string{"user_qa"}, Value: "beta"},
+			{Platforms: []string{"web"}, Modes: []string{"night"}, Value: "sticky"},
+			{MinAppVersion: "2.0", Percentage: &all, Value: "express"},
+			{Percentage: &none, Value: "sticky"},
+		},
+	}
+
+	tests := []struct {
+		name string
+		flag Flag
+		ctx  FlagContext
+		want interface{}
+	}{
+		{"no rule matches", flag, FlagContext{UserID: "u1", Platform: "ios", Mode: "day"}, "classic"},
+		{"user targeting wins first", flag, FlagContext{UserID: "user_qa", Platform: "web", Mode: "night"}, "beta"},
+		{"every condition must hold", flag, FlagContext{UserID: "u1", Platform: "web", Mode: "day"}, "classic"},
+		{"platform and mode", flag, FlagContext{UserID: "u1", Platform: "web", Mode: "night"}, "sticky"},
+		{"new enough app", flag, FlagContext{UserID: "u1", AppVersion: "2.1.0"}, "express"},
+		{"older app", flag, FlagContext{UserID: "u1", AppVersion: "1.9.9"}, "classic"},
+		{"unknown app version", flag, FlagContext{UserID: "u1"}, "classic"},
+		{"rollouts skip anonymous users", flag, FlagContext{AppVersion: "2.1.0"}, "classic"},
+		{"disabled multivariate serves first variation", Flag{Key: "f", Type: "multivariate", Default: "b", Variations: []interface{}{"a", "b"}}, FlagContext{}, "a"},
+		{"disabled boolean is off", Flag{Key: "f", Type: "boolean", Default: true}, FlagContext{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.flag.Evaluate(tt.ctx); got != tt.want {
+				t.Errorf("Evaluate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlagRollout(t *testing.T) {
+	for _, percentage := range []int{0, 10, 50, 100} {
+		flag := Flag{Key: "rollout", Type: "boolean", Enabled: true, Default: false, Rules: []FlagRule{{Percentage: &percentage, Value: true}}}
+
+		on := 0
+		for i := 0; i < 10_000; i++ {
+			ctx := FlagContext{UserID: fmt.Sprint("user_", i)}
+			value := flag.Evaluate(ctx)
+			if again := flag.Evaluate(ctx); again != value {
+				t.Fatalf("%d%%: %s flipped from %v to %v", percentage, ctx.UserID, value, again)
+			}
+			if value == true {
+				on++
+			}
+		}
+		if want := percentage * 100; on < want-300 || on > want+300 {
+			t.Errorf("%d%% rollout enabled %d of 10000 users", percentage, on)
+		}
+	}
+}
+
+func TestFlagValidate(t *testing.T) {
+	over := 101
+	tests := []struct {
+		name    string
+		flag    Flag
+		wantErr bool
+	}{
+		{"boolean", Flag{Key: "f", Type: "boolean", Default: false}, false},
+		{"missing key", Flag{Type: "boolean", Default: false}, true},
+		{"boolean with string default", Flag{Key: "f", Type: "boolean", Default: "yes"}, true},
+		{"boolean rule value", Flag{Key: "f", Type: "boolean", Default: false, Rules: []FlagRule{{Value: "on"}}}, true},
+		{"multivariate", Flag{Key: "f", Type: "multivariate", Default: "a", Variations: []interface{}{"a", "b"}}, false},
+		{"multivariate without variations", Flag{Key: "f", Type: "multivariate", Default: "a"}, true},
+		{"default outside variations", Flag{Key: "f", Type: "multivariate", Default: "c", Variations: []interface{}{"a"}}, true},
+		{"percentage over 100", Flag{Key: "f", Type: "boolean", Default: false, Rules: []FlagRule{{Percentage: &over, Value: true}}}, true},
+		{"unknown type", Flag{Key: "f", Type: "string"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.flag.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0", "1.0.0", 0},
+		{"1.10.0", "1.9", 1},
+		{"2.0", "10.0", -1},
+		{"2.0.1", "2.0", 1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestFlagConditionHolds(t *testing.T) {
+	ctx := FlagContext{UserID: "u1", Platform: "web", Mode: "morning"}
+	tests := []struct {
+		name      string
+		condition interface{}
+		want      bool
+	}{
+		{"true flag by key", "testimonials", true},
+		{"false flag by key", "flash_promo_badges", false},
+		{"unknown flag", "no_such_flag", false},
+		{"value match", map[string]interface{}{"key": "checkout_button_style", "value": "classic"}, true},
+		{"value mismatch", map[string]interface{}{"key": "checkout_button_style", "value": "express"}, false},
+		{"boolean value", map[string]interface{}{"key": "flash_promo_badges", "value": false}, true},
+		{"malformed", 42, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := flagConditionHolds(tt.condition, ctx); got != tt.want {
+				t.Errorf("flagConditionHolds(%v) = %v, want %v", tt.condition, got, tt.want)
+			}
+		})
+	}
+}
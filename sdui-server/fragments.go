@@ -0,0 +1,124 @@
+package main
+
+import "log"
+
+// ==================== FRAGMENTS ====================
+
+// fragments are reusable component shapes layouts include by name, so a
+// tweak to e.g. the standard product grid reaches every mode using it
+var fragments = map[string]func() map[string]interface{}{
+	"section-header": func() map[string]interface{} {
+		return map[string]interface{}{
+			"type": "header",
+			"props": map[string]interface{}{
+				"alignment": "left",
+			},
+			"style": map[string]interface{}{
+				"padding":  16.0,
+				"fontSize": "$font_sizes.headline",
+				"color":    "$primary",
+			},
+		}
+	},
+	"promo-banner": func() map[string]interface{} {
+		return map[string]interface{}{
+			"type": "banner",
+			"props": map[string]interface{}{
+				"height": 200.0,
+			},
+			"style": map[string]interface{}{
+				"borderRadius": 16.0,
+				"margin":       16.0,
+			},
+		}
+	},
+	"standard-product-grid": func() map[string]interface{} {
+		return map[string]interface{}{
+			"type": "product_grid",
+			"props": map[string]interface{}{
+				"columns":     2,
+				"spacing":     16.0,
+				"aspectRatio": 0.75,
+			},
+			"style": map[string]interface{}{
+				"imageHeight":    200.0,
+				"borderRadius":   12.0,
+				"showDiscount":   true,
+				"showRating":     true,
+				"showFavorite":   true,
+				"titleSize":      16.0,
+				"priceSize":      18.0,
+				"priceColor":     "$primary",
+				"elevation":      2.0,
+				"contentPadding": 12.0,
+			},
+		}
+	},
+	"product-carousel": func() map[string]interface{} {
+		return map[string]interface{}{
+			"type": "product_carousel",
+			"props": map[string]interface{}{
+				"height":    320.0,
+				"cardWidth": 200.0,
+			},
+			"style": map[string]interface{}{
+				"padding":        16.0,
+				"spacing":        12.0,
+				"borderRadius":   12.0,
+				"imageHeight":    180.0,
+				"showDiscount":   true,
+				"showRating":     true,
+				"showFavorite":   true,
+				"titleSize":      16.0,
+				"priceSize":      18.0,
+				"priceColor":     "$primary",
+				"elevation":      2.0,
+				"contentPadding": 12.0,
+			},
+		}
+	},
+}
+
+// fragment builds a named fragment with overrides deep-merged on top;
+// overrides usually carry at least the component id, and a nil value
+// leaves a fragment key out
+func fragment(name string, overrides map[string]interface{}) map[string]interface{} {
+	build, ok := fragments[name]
+	if !ok {
+		log.Printf("⚠️  Unknown fragment %q", name)
+		return overrides
+	}
+	component := build()
+	mergeDeep(component, overrides)
+	return component
+}
+
+// mergeDeep copies src into dst, merging nested maps key by key and
+// deleting keys src sets to nil
+func mergeDeep(dst, src map[string]interface{}) {
+	for key, value := range src {
+		if value == nil {
+			delete(dst, key)
+			continue
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			if existing, ok := dst[key].(map[string]interface{}); ok {
+				mergeDeep(existing, nested)
+				continue
+			}
+		}
+		dst[key] = value
+	}
+}
+
+// homeLayout wraps home screen components in the shell every mode shares
+func homeLayout(mode string, components []interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"screen_id":   "home",
+		"layout_type": "scroll",
+		"theme":       getThemeForMode(mode),
+		"components":  components,
+		"navigation":  getNavigationConfig("/", mode),
+		"metadata":    getMetadata(mode),
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func componentIDs(config map[string]interface{}) []string {
+	var ids []string
+	components, _ := config["components"].([]interface{})
+	for _, c := range components {
+		if component, ok := c.(map[string]interface{}); ok {
+			id, _ := component["id"].(string)
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Analytics and reports attribute events by component id, so home layouts
+// must keep their ids across refactors
+func TestHomeComponentIDs(t *testing.T) {
+	tests := map[string][]string{
+		"late_night": {"night-message", "spacer", "minimal-products"},
+		"morning":    {"morning-greeting", "search", "morning-stories", "morning-deals", "categories", "recently-viewed", "featured-products", "review"},
+		"flash_sale": {"flash-countdown", "promo-row", "flash-products", "urgency"},
+		"afternoon":  {"afternoon-header", "categories-horizontal", "afternoon-banner", "recently-viewed", "afternoon-products"},
+		"evening":    {"evening-header", "evening-banner", "featured-carousel", "recently-viewed", "evening-grid"},
+		"night":      {"night-hero", "spacer", "night-products"},
+		"day":        {"header", "welcome-back-header", "promo-banner", "cart-reminder", "recently-viewed", "products"},
+	}
+	for mode, want := range tests {
+		if got := componentIDs(getHomeScreenConfig(mode, "")); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: ids %v, want %v", mode, got, want)
+		}
+	}
+}
+
+func TestFragmentOverrides(t *testing.T) {
+	grid := fragment("standard-product-grid", map[string]interface{}{
+		"id":    "grid",
+		"props": map[string]interface{}{"columns": 1},
+		"style": map[string]interface{}{"titleSize": 20.0, "showFavorite": nil},
+	})
+
+	props := grid["props"].(map[string]interface{})
+	style := grid["style"].(map[string]interface{})
+	if grid["id"] != "grid" || grid["type"] != "product_grid" {
+		t.Errorf("id/type = %v/%v", grid["id"], grid["type"])
+	}
+	if props["columns"] != 1 || props["spacing"] != 16.0 {
+		t.Errorf("props = %v, want columns overridden and spacing kept", props)
+	}
+	if style["titleSize"] != 20.0 || style["priceSize"] != 18.0 {
+		t.Errorf("style = %v, want titleSize overridden and priceSize kept", style)
+	}
+	if _, ok := style["showFavorite"]; ok {
+		t.Error("nil override left showFavorite in place")
+	}
+
+	// Every call builds a fresh copy
+	again := fragment("standard-product-grid", map[string]interface{}{"id": "other"})
+	if again["style"].(map[string]interface{})["showFavorite"] != true {
+		t.Error("an override leaked into the shared fragment")
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ==================== MESSAGE CATALOG ====================
+
+const defaultLocale = "en"
+
+// Translations live in locales/<locale>.json as a flat key -> message map.
+// Plural messages are objects keyed by CLDR category ("one", "other", ...)
+// with {count} standing in for the number.
+//
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+type message struct {
+	Text   string
+	Plural map[string]string
+}
+
+func (m *message) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &m.Text); err == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, &m.Plural); err != nil {
+		return fmt.Errorf("message must be a string or plural object")
+	}
+	return nil
+}
+
+// messageCatalog holds every locale's messages and the keys lookups missed
+type messageCatalog struct {
+	locales map[string]map[string]message
+
+	mu      sync.Mutex
+	missing map[string]map[string]int // locale -> key -> misses
+}
+
+var catalog = loadCatalog()
+
+func loadCatalog() *messageCatalog {
+	c := &messageCatalog{
+		locales: make(map[string]map[string]message),
+		missing: make(map[string]map[string]int),
+	}
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		log.Fatalf("❌ Failed to read locales: %v", err)
+	}
+	for _, file := range files {
+		data, err := localeFiles.ReadFile("locales/" + file.Name())
+		if err != nil {
+			log.Fatalf("❌ Failed to read %s: %v", file.Name(), err)
+		}
+		messages := map[string]message{}
+		if err := json.Unmarshal(data, &messages); err != nil {
+			log.Fatalf("❌ Invalid locale file %s: %v", file.Name(), err)
+		}
+		c.locales[strings.TrimSuffix(file.Name(), path.Ext(file.Name()))] = messages
+	}
+	return c
+}
+
+// fallbackChain lists the locales to try for a locale: "es-MX" -> es-MX, es, en
+func (c *messageCatalog) fallbackChain(locale string) []string {
+	var chain []string
+	for _, candidate := range []string{locale, baseLanguage(locale), defaultLocale} {
+		if _, ok := c.locales[candidate]; ok && !slices.Contains(chain, candidate) {
+			chain = append(chain, candidate)
+		}
+	}
+	return chain
+}
+
+// lookup finds key along the locale's fallback chain, recording a miss for
+// every catalog that didn't have it
+func (c *messageCatalog) lookup(locale, key string) (message, string, bool) {
+	for _, candidate := range c.fallbackChain(locale) {
+		if entry, ok := c.locales[candidate][key]; ok {
+			return entry, candidate, true
+		}
+		c.recordMissing(candidate, key)
+	}
+	return message{}, "", false
+}
+
+func (c *messageCatalog) recordMissing(locale, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.missing[locale] == nil {
+		c.missing[locale] = make(map[string]int)
+	}
+	if c.missing[locale][key] == 0 {
+		log.Printf("🌐 Missing translation: %s/%s", locale, key)
+	}
+	c.missing[locale][key]++
+}
+
+func (c *messageCatalog) missingReport() map[string]map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	report := make(map[string]map[string]int, len(c.missing))
+	for locale, keys := range c.missing {
+		report[locale] = make(map[string]int, len(keys))
+		for key, count := range keys {
+			report[locale][key] = count
+		}
+	}
+	return report
+}
+
+func (c *messageCatalog) available() []string {
+	names := make([]string, 0, len(c.locales))
+	for name := range c.locales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// translate renders key for the locale. With a count it picks the plural
+// form and fills {count}. Unknown keys come back as the key itself.
+func translate(locale, key string, count *float64) (string, bool) {
+	entry, found, ok := catalog.lookup(locale, key)
+	if !ok {
+		return key, false
+	}
+	if entry.Plural == nil {
+		return entry.Text, true
+	}
+
+	n := 0.0
+	if count != nil {
+		n = *count
+	}
+	text, ok := entry.Plural[pluralCategory(found, n)]
+	if !ok {
+		text = entry.Plural["other"]
+	}
+	return strings.ReplaceAll(text, "{count}", strconv.FormatFloat(n, 'f', -1, 64)), true
+}
+
+// pluralCategory applies the CLDR cardinal rules for the languages we ship
+func pluralCategory(locale string, n float64) string {
+	integer := n == float64(int(n))
+	i := int(n)
+	switch baseLanguage(locale) {
+	case "ar":
+		switch {
+		case n == 0:
+			return "zero"
+		case n == 1:
+			return "one"
+		case n == 2:
+			return "two"
+		case integer && i%100 >= 3 && i%100 <= 10:
+			return "few"
+		case integer && i%100 >= 11:
+			return "many"
+		}
+		return "other"
+	case "he":
+		switch n {
+		case 1:
+			return "one"
+		case 2:
+			return "two"
+		}
+		return "other"
+	case "fr":
+		if n >= 0 && n < 2 {
+			return "one"
+		}
+		return "other"
+	}
+	if n == 1 {
+		return "one"
+	}
+	return "other"
+}
+
+// msg marks a layout string as a catalog message, rendered per request
+// by the templating pass in the caller's locale
+func msg(key string) string {
+	return `{{t "` + key + `"}}`
+}
+
+// ==================== LOCALE NEGOTIATION ====================
+
+// negotiateLocale picks the best supported locale from ?locale= or the
+// Accept-Language header, defaulting to English
+func negotiateLocale(r *http.Request) string {
+	if requested := r.URL.Query().Get("locale"); requested != "" {
+		if locale, ok := supportedLocale(requested); ok {
+			return locale
+		}
+	}
+
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q <= 0 {
+			continue // q=0 means "not acceptable"
+		}
+		tags = append(tags, weighted{tag, q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	for _, t := range tags {
+		if locale, ok := supportedLocale(t.tag); ok {
+			return locale
+		}
+	}
+	return defaultLocale
+}
+
+// supportedLocale maps a language tag onto a catalog, trying the exact
+// tag and then its base language
+func supportedLocale(tag string) (string, bool) {
+	tag = strings.ReplaceAll(strings.TrimSpace(tag), "_", "-")
+	for name := range catalog.locales {
+		if strings.EqualFold(name, tag) {
+			return name, true
+		}
+	}
+	base := baseLanguage(tag)
+	if _, ok := catalog.locales[base]; ok {
+		return base, true
+	}
+	return "", false
+}
+
+func baseLanguage(tag string) string {
+	base, _, _ := strings.Cut(tag, "-")
+	return strings.ToLower(base)
+}
+
+// ==================== HANDLER ====================
+
+// handleMissingTranslations reports catalog keys lookups fell through on
+func handleMissingTranslations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"locales": catalog.available(),
+		"missing": catalog.missingReport(),
+	})
+}
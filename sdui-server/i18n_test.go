@@ -0,0 +1,118 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Every shipped locale translates every English key, so nothing silently
+// falls back to English
+func TestCatalogComplete(t *testing.T) {
+	for _, locale := range catalog.available() {
+		for key := range catalog.locales[defaultLocale] {
+			if _, ok := catalog.locales[locale][key]; !ok {
+				t.Errorf("%s.json is missing %q", locale, key)
+			}
+		}
+	}
+}
+
+func TestNegotiateLocale(t *testing.T) {
+	tests := []struct {
+		query, acceptLanguage string
+		want                  string
+	}{
+		{"", "", "en"},
+		{"", "fr", "fr"},
+		{"", "fr-CA", "fr"},
+		{"", "es_MX", "es"},
+		{"", "de, ar;q=0.5", "ar"},
+		{"", "fr;q=0.4, he;q=0.8", "he"},
+		{"", "fr;q=0, es", "es"},
+		{"", "fr;q=0", "en"},
+		{"", "*", "en"},
+		{"es", "fr", "es"},
+		{"xx", "fr", "fr"},
+	}
+	for _, tt := range tests {
+		target := "/api/ui-config"
+		if tt.query != "" {
+			target += "?locale=" + tt.query
+		}
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Accept-Language", tt.acceptLanguage)
+		if got := negotiateLocale(req); got != tt.want {
+			t.Errorf("locale=%q Accept-Language %q: got %q, want %q", tt.query, tt.acceptLanguage, got, tt.want)
+		}
+	}
+}
+
+func TestPluralCategory(t *testing.T) {
+	tests := []struct {
+		locale string
+		n      float64
+		want   string
+	}{
+		{"en", 1, "one"},
+		{"en", 0, "other"},
+		{"en", 2, "other"},
+		{"fr", 0, "one"},
+		{"fr", 1.5, "one"},
+		{"fr", 2, "other"},
+		{"he", 2, "two"},
+		{"ar", 0, "zero"},
+		{"ar", 2, "two"},
+		{"ar", 5, "few"},
+		{"ar", 11, "many"},
+		{"ar", 100, "other"},
+	}
+	for _, tt := range tests {
+		if got := pluralCategory(tt.locale, tt.n); got != tt.want {
+			t.Errorf("pluralCategory(%q, %v) = %q, want %q", tt.locale, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestTranslateNestedMessages(t *testing.T) {
+	quietLogs(t)
+	messages := map[string]message{
+		"test.outer": {Text: `[{{t "test.inner"}}]`},
+		"test.inner": {Text: "inner"},
+		"test.self":  {Text: `again {{t "test.self"}}`},
+		"test.ping":  {Text: `{{t "test.pong"}}`},
+		"test.pong":  {Text: `{{t "test.ping"}}`},
+	}
+	for key, m := range messages {
+		catalog.locales["en"][key] = m
+	}
+	t.Cleanup(func() {
+		for key := range messages {
+			delete(catalog.locales["en"], key)
+		}
+	})
+
+	vars := map[string]interface{}{"locale": "en"}
+	tests := []struct {
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{`{{t "test.outer"}}`, "[inner]", false},
+		{`{{t "test.self"}}`, "", true},
+		{`{{t "test.ping"}}`, "", true},
+	}
+	for _, tt := range tests {
+		got, err := renderTemplate(tt.template, vars)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, want error %v", tt.template, err, tt.wantErr)
+		}
+		if err == nil && got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.template, got, tt.want)
+		}
+		if err != nil && !strings.Contains(err.Error(), "deep") {
+			t.Errorf("%s: unexpected error %v", tt.template, err)
+		}
+	}
+}
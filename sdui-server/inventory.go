@@ -0,0 +1,86 @@
+package main
+
+import (
+	"sync"
+)
+
+// ==================== INVENTORY ====================
+
+const lowStockThreshold = 10
+
+// inventoryStore tracks units on hand per product. Every change is pushed
+// to live clients as a patch of the product screen's stock badge.
+type inventoryStore struct {
+	mu    sync.Mutex
+	stock map[string]int
+}
+
+var inventory = newInventory()
+
+func newInventory() *inventoryStore {
+	s := &inventoryStore{stock: make(map[string]int)}
+	for i, p := range getAllProducts() {
+		s.stock[p.ID] = 6 + i*4
+	}
+	return s
+}
+
+func (s *inventoryStore) level(productID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stock[productID]
+}
+
+// adjust changes a product's stock by delta, never below zero
+func (s *inventoryStore) adjust(productID string, delta int) int {
+	s.mu.Lock()
+	level := max(s.stock[productID]+delta, 0)
+	s.stock[productID] = level
+	s.mu.Unlock()
+
+	renderCache.invalidate("stock_change", func(key renderKey) bool {
+		return key.ProductID == productID
+	})
+	liveUpdates.publish(LiveUpdate{
+		Screen:      "/product",
+		ComponentID: stockBadgeID(productID),
+		Props:       stockBadgeProps(level),
+		Vars:        map[string]interface{}{"product.stock": level},
+	})
+	return level
+}
+
+func stockBadgeID(productID string) string {
+	return "stock-" + productID
+}
+
+func stockBadgeProps(level int) map[string]interface{} {
+	text := msg("product.in_stock")
+	switch {
+	case level == 0:
+		text = msg("product.sold_out")
+	case level <= lowStockThreshold:
+		text = `{{product.stock | t "product.stock_left"}}`
+	}
+	return map[string]interface{}{
+		"text":  text,
+		"stock": level,
+	}
+}
+
+// stockBadge is the product screen's stock indicator, kept current over
+// the live socket
+func stockBadge(productID string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":    stockBadgeID(productID),
+		"type":  "promo_badge",
+		"props": stockBadgeProps(inventory.level(productID)),
+		"style": map[string]interface{}{
+			"backgroundColor": "$primary",
+			"paddingX":        12.0,
+			"paddingY":        6.0,
+			"borderRadius":    12.0,
+			"fontSize":        12.0,
+		},
+	}
+}
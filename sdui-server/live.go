@@ -0,0 +1,347 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ==================== LIVE COMPONENT UPDATES ====================
+
+const (
+	liveSendBuffer   = 32
+	liveWriteTimeout = 10 * time.Second
+	livePongTimeout  = 60 * time.Second
+	livePingInterval = 50 * time.Second
+	liveMaxMessage   = 4096
+)
+
+// LiveUpdate patches one component on screens clients already rendered.
+// String props may be templates ({{t "key"}}, {{product.stock}}, ...); they are
+// rendered once per locale with Vars added to the usual template variables.
+// An empty Screen reaches every subscriber.
+type LiveUpdate struct {
+	Screen      string                 `json:"screen"`
+	ComponentID string                 `json:"component_id"`
+	Props       map[string]interface{} `json:"props,omitempty"`
+	Style       map[string]interface{} `json:"style,omitempty"`
+	Vars        map[string]interface{} `json:"vars,omitempty"`
+}
+
+// liveClient is one WebSocket connection and the screens it follows
+type liveClient struct {
+	conn    *websocket.Conn
+	locale  string
+	send    chan []byte
+	screens map[string]bool // guarded by the hub's lock
+	once    sync.Once
+}
+
+func (c *liveClient) close() {
+	c.once.Do(func() { close(c.send) })
+}
+
+// liveHub indexes clients by screen. Publishing never waits on a client:
+// each message is encoded once per locale and handed to buffered queues,
+// and clients whose queue is full are disconnected.
+type liveHub struct {
+	seq      uint64 // atomic
+	mu       sync.RWMutex
+	byScreen map[string]map[*liveClient]bool
+	clients  map[*liveClient]bool
+}
+
+var liveUpdates = &liveHub{
+	byScreen: make(map[string]map[*liveClient]bool),
+	clients:  make(map[*liveClient]bool),
+}
+
+func (h *liveHub) register(c *liveClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clients[c] = true
+}
+
+func (h *liveHub) unregister(c *liveClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for screen := range c.screens {
+		delete(h.byScreen[screen], c)
+	}
+	delete(h.clients, c)
+	c.close()
+}
+
+func (h *liveHub) subscribe(c *liveClient, screens []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.clients[c] {
+		return // already dropped
+	}
+	for _, screen := range screens {
+		screen = normalizeScreen(screen)
+		if h.byScreen[screen] == nil {
+			h.byScreen[screen] = make(map[*liveClient]bool)
+		}
+		h.byScreen[screen][c] = true
+		c.screens[screen] = true
+	}
+}
+
+func (h *liveHub) unsubscribe(c *liveClient, screens []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, screen := range screens {
+		screen = normalizeScreen(screen)
+		delete(h.byScreen[screen], c)
+		delete(c.screens, screen)
+	}
+}
+
+// publish fans an update out to the screen's subscribers and reports how
+// many clients it was queued for. Sends happen under the read lock so no
+// client's queue can be closed mid-send.
+func (h *liveHub) publish(update LiveUpdate) (uint64, int) {
+	seq := atomic.AddUint64(&h.seq, 1)
+	encoded := map[string][]byte{}
+	var slow []*liveClient
+	delivered := 0
+
+	h.mu.RLock()
+	targets := h.clients
+	if update.Screen != "" {
+		targets = h.byScreen[normalizeScreen(update.Screen)]
+	}
+	for c := range targets {
+		message, ok := encoded[c.locale]
+		if !ok {
+			message = encodeLiveUpdate(update, seq, c.locale)
+			encoded[c.locale] = message
+		}
+		select {
+		case c.send <- message:
+			delivered++
+		default:
+			slow = append(slow, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, c := range slow {
+		log.Printf("🐢 Dropping slow live client (%d queued)", len(c.send))
+		h.unregister(c)
+	}
+	return seq, delivered
+}
+
+// encodeLiveUpdate renders the update's templates for a locale and encodes
+// the patch message clients receive
+func encodeLiveUpdate(update LiveUpdate, seq uint64, locale string) []byte {
+	vars := templateVars(RenderContext{Screen: update.Screen, Mode: getCurrentMode(), Now: time.Now(), Locale: locale})
+	for key, value := range update.Vars {
+		vars[key] = value
+	}
+
+	message := map[string]interface{}{
+		"type":         "patch",
+		"seq":          seq,
+		"screen":       update.Screen,
+		"component_id": update.ComponentID,
+		"sent_at":      time.Now().Format(time.RFC3339),
+	}
+	var problems []string
+	for key, values := range map[string]map[string]interface{}{"props": update.Props, "style": update.Style} {
+		if values != nil {
+			message[key] = renderTemplateValue(cloneJSONMap(values), key, vars, &problems)
+		}
+	}
+	for _, problem := range problems {
+		log.Printf("⚠️  Live update template error: %s", problem)
+	}
+
+	encoded, _ := json.Marshal(message)
+	return encoded
+}
+
+// cloneJSONMap deep-copies a JSON-shaped map so rendering one locale's
+// copy leaves the original templates intact
+func cloneJSONMap(values map[string]interface{}) map[string]interface{} {
+	encoded, _ := json.Marshal(values)
+	var clone map[string]interface{}
+	json.Unmarshal(encoded, &clone)
+	return clone
+}
+
+// ==================== HANDLERS ====================
+
+var liveUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// The API is open to any origin (see enableCORS)
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// liveRequest is what clients send over the socket:
+//
+//	{"type": "subscribe", "screens": ["/", "/product"]}
+//	{"type": "unsubscribe", "screens": ["/product"]}
+//	{"type": "ping"}
+type liveRequest struct {
+	Type    string   `json:"type"`
+	Screens []string `json:"screens"`
+}
+
+// handleLiveSocket upgrades GET /api/ws to a WebSocket carrying component
+// patches for the screens the client subscribes to. ?screen= subscribes
+// straight away.
+func handleLiveSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := liveUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written the error response
+		log.Printf("⚠️  WebSocket upgrade failed: %v", err)
+		return
+	}
+
+	client := &liveClient{
+		conn:    conn,
+		locale:  negotiateLocale(r),
+		send:    make(chan []byte, liveSendBuffer),
+		screens: make(map[string]bool),
+	}
+	liveUpdates.register(client)
+	if screens := r.URL.Query()["screen"]; len(screens) > 0 {
+		liveUpdates.subscribe(client, screens)
+	}
+	log.Printf("🔌 Live client connected (locale=%s)", client.locale)
+
+	go client.writePump()
+	client.readPump()
+}
+
+// readPump handles subscription requests until the connection drops
+func (c *liveClient) readPump() {
+	defer func() {
+		liveUpdates.unregister(c)
+		c.conn.Close()
+		log.Printf("🔌 Live client disconnected")
+	}()
+
+	c.conn.SetReadLimit(liveMaxMessage)
+	c.conn.SetReadDeadline(time.Now().Add(livePongTimeout))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(livePongTimeout))
+	})
+
+	for {
+		var request liveRequest
+		if err := c.conn.ReadJSON(&request); err != nil {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+				c.reply(map[string]interface{}{"type": "error", "message": "invalid JSON"})
+				continue
+			}
+			return
+		}
+
+		switch request.Type {
+		case "subscribe":
+			liveUpdates.subscribe(c, request.Screens)
+			c.reply(map[string]interface{}{"type": "subscribed", "screens": request.Screens})
+		case "unsubscribe":
+			liveUpdates.unsubscribe(c, request.Screens)
+			c.reply(map[string]interface{}{"type": "unsubscribed", "screens": request.Screens})
+		case "ping":
+			c.reply(map[string]interface{}{"type": "pong"})
+		default:
+			c.reply(map[string]interface{}{"type": "error", "message": "unknown message type " + request.Type})
+		}
+	}
+}
+
+// reply queues a direct response without ever blocking the read loop
+func (c *liveClient) reply(message map[string]interface{}) {
+	encoded, _ := json.Marshal(message)
+	liveUpdates.mu.RLock()
+	defer liveUpdates.mu.RUnlock()
+	if !liveUpdates.clients[c] {
+		return
+	}
+	select {
+	case c.send <- encoded:
+	default:
+	}
+}
+
+// writePump is the connection's only writer: queued messages plus pings
+func (c *liveClient) writePump() {
+	ping := time.NewTicker(livePingInterval)
+	defer func() {
+		ping.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case message, open := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+			if !open {
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+		case <-ping.C:
+			c.conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// handleLivePublish pushes a component patch to live clients from the
+// admin API, e.g. to extend a countdown mid-campaign. Catalog (inventory)
+// and scheduler (countdown) patches are published by the server itself.
+func handleLivePublish(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var update LiveUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if update.ComponentID == "" {
+		http.Error(w, "component_id is required", http.StatusBadRequest)
+		return
+	}
+
+	seq, delivered := liveUpdates.publish(update)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"seq":       seq,
+		"delivered": delivered,
+	})
+}
+
+// closeAll disconnects every live client so the server can shut down
+func (h *liveHub) closeAll() {
+	h.mu.Lock()
+	clients := make([]*liveClient, 0, len(h.clients))
+	for c := range h.clients {
+		clients = append(clients, c)
+	}
+	h.mu.Unlock()
+	for _, c := range clients {
+		h.unregister(c)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// subscribeTestClient registers a connection-less client on screens
+func subscribeTestClient(t *testing.T, locale string, screens ...string) *liveClient {
+	t.Helper()
+	c := &liveClient{locale: locale, send: make(chan []byte, liveSendBuffer), screens: map[string]bool{}}
+	liveUpdates.register(c)
+	liveUpdates.subscribe(c, screens)
+	t.Cleanup(func() { liveUpdates.unregister(c) })
+	return c
+}
+
+func receivePatch(t *testing.T, c *liveClient) map[string]interface{} {
+	t.Helper()
+	select {
+	case message := <-c.send:
+		var patch map[string]interface{}
+		if err := json.Unmarshal(message, &patch); err != nil {
+			t.Fatalf("bad patch %s: %v", message, err)
+		}
+		return patch
+	default:
+		t.Fatal("no patch queued")
+		return nil
+	}
+}
+
+func TestLivePublishTargetsScreen(t *testing.T) {
+	home := subscribeTestClient(t, "en", "/")
+	product := subscribeTestClient(t, "en", "/product")
+
+	_, delivered := liveUpdates.publish(LiveUpdate{Screen: "/product", ComponentID: "stock-prod_1", Props: map[string]interface{}{"text": "x"}})
+	if delivered != 1 {
+		t.Errorf("delivered = %d, want 1", delivered)
+	}
+	if patch := receivePatch(t, product); patch["component_id"] != "stock-prod_1" {
+		t.Errorf("component_id = %v", patch["component_id"])
+	}
+	if len(home.send) != 0 {
+		t.Error("home subscriber got a /product patch")
+	}
+}
+
+func TestPublishCountdowns(t *testing.T) {
+	flashStart := time.Date(2025, 6, 1, 13, 0, 0, 0, time.Local)
+	flashEnd := time.Date(2025, 6, 1, 14, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name    string
+		mode    string
+		now     time.Time
+		wantEnd time.Time
+	}{
+		{"running window resyncs its end", "flash_sale", flashStart, flashEnd},
+		{"closed window stops at zero", "flash_sale", flashEnd, flashEnd},
+		{"closed window, late tick", "flash_sale", flashEnd.Add(5 * time.Minute), flashEnd.Add(5 * time.Minute)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := subscribeTestClient(t, "en", "/")
+			if sent := publishCountdowns(tt.mode, tt.now); sent != 1 {
+				t.Fatalf("published %d countdowns, want 1", sent)
+			}
+			patch := receivePatch(t, c)
+			props, _ := patch["props"].(map[string]interface{})
+			if patch["component_id"] != "flash-countdown" {
+				t.Errorf("component_id = %v", patch["component_id"])
+			}
+			if props["end_time"] != tt.wantEnd.Format(time.RFC3339) {
+				t.Errorf("end_time = %v, want %s", props["end_time"], tt.wantEnd.Format(time.RFC3339))
+			}
+			if props["server_time"] != tt.now.Format(time.RFC3339) {
+				t.Errorf("server_time = %v, want %s", props["server_time"], tt.now.Format(time.RFC3339))
+			}
+		})
+	}
+}
+
+func TestPublishCountdownsNoTimers(t *testing.T) {
+	if sent := publishCountdowns("evening", time.Date(2025, 6, 1, 19, 0, 0, 0, time.Local)); sent != 0 {
+		t.Errorf("published %d countdowns for a layout without timers", sent)
+	}
+}
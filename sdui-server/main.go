@@ -1,41 +1,114 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
 func main() {
 	// Initialize server
+	initAnalytics()
+
 	mux := http.NewServeMux()
 
 	// Routes
 	mux.HandleFunc("/api/ui-config", handleUiConfig)
+	mux.HandleFunc("/api/ui-stream", handleUiStream)
+	mux.HandleFunc("/api/ws", handleLiveSocket)
 	mux.HandleFunc("/api/products/", handleProductDetail)
 	mux.HandleFunc("/api/analytics", handleAnalytics)
 	mux.HandleFunc("/health", handleHealth)
 
-	// CORS middleware
-	handler := enableCORS(mux)
+	// Auth & user data
+	mux.HandleFunc("/api/auth/register", handleRegister)
+	mux.HandleFunc("/api/auth/login", handleLogin)
+	mux.HandleFunc("/api/auth/refresh", handleRefresh)
+	mux.HandleFunc("/api/auth/guest", handleGuestSession)
+	mux.HandleFunc("/api/me", requireSession(handleMe))
+	mux.HandleFunc("/api/cart", requireSession(handleCart))
+	mux.HandleFunc("/api/favorites", requireSession(handleFavorites))
+	mux.HandleFunc("/api/orders", requireSession(handleOrders))
+	mux.HandleFunc("/api/me/segments", handleMySegments)
+	mux.HandleFunc("/api/me/recent", requireSession(handleRecentlyViewed))
+
+	// Reporting & experiments
+	mux.HandleFunc("/api/reports/", requireAdmin(handleReports))
+	mux.HandleFunc("/api/admin/analytics", requireAdmin(handleAnalyticsStats))
+	mux.HandleFunc("/api/experiments", handleExperiments)
+
+	// Feature flags
+	mux.HandleFunc("/api/flags", handleFlags)
+	mux.HandleFunc("/api/admin/flags", requireAdmin(handleFlagAdmin))
+	mux.HandleFunc("/api/admin/flags/", requireAdmin(handleFlagAdmin))
+	mux.HandleFunc("/api/admin/contrast", requireAdmin(handleContrastReport))
+	mux.HandleFunc("/api/admin/i18n/missing", requireAdmin(handleMissingTranslations))
+	mux.HandleFunc("/api/admin/live", requireAdmin(handleLivePublish))
+
+	// Auth + CORS middleware
+	handler := enableCORS(withAuth(mux))
 
 	// Start server
 	port := ":8080"
 	fmt.Printf("🚀 Shape-Shifting Store Server running on http://localhost%s\n", port)
 	fmt.Println("📡 Endpoints:")
 	fmt.Println("   GET  /api/ui-config?screen=<name>")
+	fmt.Println("   GET  /api/ui-stream?screen=<name>  (SSE)")
+	fmt.Println("   GET  /api/ws?screen=<name>  (WebSocket)")
 	fmt.Println("   GET  /api/products/<id>")
 	fmt.Println("   POST /api/analytics")
 	fmt.Println("   GET  /health")
+	fmt.Println("   POST /api/auth/{register,login,refresh,guest}")
+	fmt.Println("   GET  /api/me")
+	fmt.Println("   *    /api/cart, /api/favorites, /api/orders")
+	fmt.Println("   GET  /api/me/segments")
+	fmt.Println("   GET  /api/me/recent")
+	fmt.Println("   GET  /api/reports/{overview,components}")
+	fmt.Println("   GET  /api/experiments")
+	fmt.Println("   GET  /api/flags")
+	fmt.Println("   *    /api/admin/flags/<key>")
+	fmt.Println("   GET  /api/admin/contrast")
+	fmt.Println("   GET  /api/admin/i18n/missing")
+	fmt.Println("   POST /api/admin/live")
 	fmt.Println("\n⏰ Server will automatically change UI based on time:")
 	fmt.Println("   🌙 Night Mode:     8PM - 8AM  (Minimal Boutique)")
 	fmt.Println("   ☀️  Day Mode:       8AM - 12PM (Standard Shop)")
 	fmt.Println("   🔥 Flash Sale:     12PM - 2PM (Urgent, Dense)")
 	fmt.Println("   🌆 Evening Mode:   6PM - 8PM  (Curated)")
 
-	log.Fatal(http.ListenAndServe(port, handler))
+	server := &http.Server{Addr: port, Handler: handler}
+	// Open UI streams never go idle, so end them when shutdown starts
+	server.RegisterOnShutdown(uiChanges.closeAll)
+	server.RegisterOnShutdown(liveUpdates.closeAll)
+
+	// Flush buffered analytics on Ctrl+C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go watchModeChanges(ctx)
+	go watchCountdowns(ctx)
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+	// ListenAndServe returns as soon as Shutdown starts; wait for in-flight
+	// handlers to finish before closing the analytics buffer they write to
+	<-shutdownDone
+	analytics.Close()
+	log.Println("👋 Server stopped")
 }
 
 // CORS middleware
@@ -43,7 +116,8 @@ func enableCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Admin-Key, X-Accessibility, X-Base-Revision, X-Platform, X-App-Version, If-None-Match")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag, X-Config-Revision, X-Base-Revision")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -63,24 +137,6 @@ func handleHealth(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// Analytics endpoint (just logs for demo)
-func handleAnalytics(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	var event map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	log.Printf("📊 Analytics: %v", event)
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
-}
-
 // Get current mode based on time
 // func getCurrentMode() string {
 // 	now := time.Now()
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	// Handlers log exposures and purchases; give them a pipeline with no
+	// sinks so tests never touch data/
+	analytics = newAnalyticsPipeline(1<<16, 500, time.Hour)
+	code := m.Run()
+	analytics.Close()
+	os.Exit(code)
+}
+
+// quietLogs silences the server's request logging for one test
+func quietLogs(tb testing.TB) {
+	previous := log.Writer()
+	log.SetOutput(io.Discard)
+	tb.Cleanup(func() { log.SetOutput(previous) })
+}
+
+// Browsers only send the client headers ui-config reads once CORS allows
+// them, and caches must key the config on each of them
+func TestUiConfigClientHeaders(t *testing.T) {
+	quietLogs(t)
+	preflight := httptest.NewRecorder()
+	enableCORS(http.NotFoundHandler()).ServeHTTP(preflight, httptest.NewRequest(http.MethodOptions, "/api/ui-config", nil))
+	allowed := preflight.Header().Get("Access-Control-Allow-Headers")
+	vary := getUiConfig(t, "/api/ui-config?screen=/", nil).Header().Get("Vary")
+
+	for _, header := range []string{"X-Accessibility", "X-Base-Revision", "X-Platform", "X-App-Version"} {
+		if !strings.Contains(allowed, header) {
+			t.Errorf("Access-Control-Allow-Headers = %q, missing %s", allowed, header)
+		}
+		if !strings.Contains(vary, header) {
+			t.Errorf("Vary = %q, missing %s", vary, header)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 )
 
 // Product struct
@@ -14,6 +15,7 @@ type Product struct {
 	Price       float64 `json:"price"`
 	ImageURL    string  `json:"image_url"`
 	Description string  `json:"description"`
+	Category    string  `json:"category,omitempty"`
 	Discount    *int    `json:"discount,omitempty"`
 }
 
@@ -22,9 +24,35 @@ func handleProductDetail(w http.ResponseWriter, r *http.Request) {
 	productID := strings.TrimPrefix(r.URL.Path, "/api/products/")
 
 	product := getProductByID(productID)
+	if _, ok := findProduct(productID); ok {
+		// Views of the product endpoint feed recommendations, reports and
+		// the recently viewed backfill like client product_view events
+		event := AnalyticsEvent{
+			ID:         "evt_" + randomID(8),
+			Type:       "product_view",
+			Data:       map[string]interface{}{"product_id": productID},
+			ServerTime: time.Now().UTC(),
+			Mode:       getCurrentMode(),
+			UserID:     currentUserID(r),
+			Screen:     "/product",
+		}
+		analytics.Enqueue(event)
+		recentViews.record(event.UserID, productID, event.ServerTime)
+	}
 
+	body, err := json.Marshal(product)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	etag := contentETag(body)
+	// Every view has to reach the server to be recorded as recently viewed
+	setCacheHeaders(w, etag, "public", 0, time.Now())
+	if notModified(w, r, etag) {
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(product)
+	w.Write(append(body, '\n'))
 }
 
 func getProductByID(id string) Product {
@@ -59,6 +87,7 @@ func getAllProducts() []Product {
 			Price:       599.98, // Doubled from 299.99
 			ImageURL:    "https://images.unsplash.com/photo-1551028719-00167b16eac5",
 			Description: "Handcrafted Italian leather",
+			Category:    "outerwear",
 		},
 		{
 			ID:          "prod_2",
@@ -66,6 +95,7 @@ func getAllProducts() []Product {
 			Price:       799.98, // Doubled from 399.99
 			ImageURL:    "https://images.unsplash.com/photo-1595777457583-95e059d581b8",
 			Description: "Elegant and timeless",
+			Category:    "apparel",
 		},
 		{
 			ID:          "prod_3",
@@ -73,6 +103,7 @@ func getAllProducts() []Product {
 			Price:       319.98, // Doubled from 159.99
 			ImageURL:    "https://images.unsplash.com/photo-1572635196237-14b3f281503f",
 			Description: "UV protection with style",
+			Category:    "accessories",
 			Discount:    &discount15,
 		},
 		{
@@ -81,6 +112,7 @@ func getAllProducts() []Product {
 			Price:       499.98, // Doubled from 249.99
 			ImageURL:    "https://images.unsplash.com/photo-1576566588028-4147f3842f27",
 			Description: "Luxuriously soft",
+			Category:    "apparel",
 		},
 		{
 			ID:          "prod_5",
@@ -88,6 +120,7 @@ func getAllProducts() []Product {
 			Price:       379.98, // Doubled from 189.99
 			ImageURL:    "https://images.unsplash.com/photo-1614252369475-531eba835eb1",
 			Description: "Handmade in Italy",
+			Category:    "footwear",
 			Discount:    &discount25,
 		},
 		{
@@ -96,6 +129,7 @@ func getAllProducts() []Product {
 			Price:       899.98, // Doubled from 449.99
 			ImageURL:    "https://images.unsplash.com/photo-1523275335684-37898b6baf30",
 			Description: "Swiss movement",
+			Category:    "accessories",
 		},
 		{
 			ID:          "prod_7",
@@ -103,6 +137,7 @@ func getAllProducts() []Product {
 			Price:       999.98, // Doubled from 499.99
 			ImageURL:    "https://images.unsplash.com/photo-1539533018447-63fcce2678e3",
 			Description: "Winter elegance",
+			Category:    "outerwear",
 		},
 		{
 			ID:          "prod_8",
@@ -110,6 +145,7 @@ func getAllProducts() []Product {
 			Price:       699.98, // Doubled from 349.99
 			ImageURL:    "https://images.unsplash.com/photo-1584917865442-de89df76afd3",
 			Description: "Spacious and stylish",
+			Category:    "accessories",
 			Discount:    &discount40,
 		},
 	}
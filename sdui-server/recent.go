@@ -0,0 +1,231 @@
+package main
+
+import (
+	"container/list"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ==================== RECENTLY VIEWED ====================
+
+const recentlyViewedCap = 20
+
+// The store keeps at most recentUsersMax users, forgetting the least
+// recently active first and anyone idle for recentUserTTL. A forgotten user
+// is backfilled again from stored events on their next visit.
+const (
+	recentUsersMax = 10_000
+	recentUserTTL  = 24 * time.Hour
+)
+
+type recentView struct {
+	ProductID string    `json:"product_id"`
+	ViewedAt  time.Time `json:"viewed_at"`
+}
+
+// recentUser is one user's history and when the store last touched it
+type recentUser struct {
+	userID  string
+	views   []recentView
+	touched time.Time
+}
+
+// recentStore keeps each user's most recently viewed products, newest
+// first, de-duplicated and capped at recentlyViewedCap. Users the store
+// doesn't hold are backfilled from stored product_view events.
+type recentStore struct {
+	mu       sync.Mutex
+	maxUsers int
+	ttl      time.Duration
+	order    *list.List // of *recentUser, most recently used first
+	users    map[string]*list.Element
+	backfill func(userID string) []recentView
+}
+
+var recentViews = newRecentStore(recentUsersMax, recentUserTTL)
+
+func newRecentStore(maxUsers int, ttl time.Duration) *recentStore {
+	return &recentStore{
+		maxUsers: maxUsers,
+		ttl:      ttl,
+		order:    list.New(),
+		users:    make(map[string]*list.Element),
+		backfill: loadRecentViews,
+	}
+}
+
+// record moves productID to the front of the user's list
+func (s *recentStore) record(userID, productID string, at time.Time) {
+	if userID == "" || productID == "" {
+		return
+	}
+
+	user := s.lockUser(userID, time.Now())
+	defer s.mu.Unlock()
+
+	views := []recentView{{ProductID: productID, ViewedAt: at}}
+	for _, view := range user.views {
+		if view.ProductID != productID && len(views) < recentlyViewedCap {
+			views = append(views, view)
+		}
+	}
+	user.views = views
+}
+
+func (s *recentStore) forUser(userID string) []recentView {
+	if userID == "" {
+		return nil
+	}
+
+	user := s.lockUser(userID, time.Now())
+	defer s.mu.Unlock()
+	return append([]recentView(nil), user.views...)
+}
+
+// merge folds a guest's history into an account, keeping the newest views
+func (s *recentStore) merge(fromID, toID string) {
+	guest := s.forUser(fromID)
+	for i := len(guest) - 1; i >= 0; i-- {
+		s.record(toID, guest[i].ProductID, guest[i].ViewedAt)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if element, ok := s.users[fromID]; ok {
+		s.order.Remove(element)
+		delete(s.users, fromID)
+	}
+}
+
+// lockUser returns the user's entry with s.mu held; the caller unlocks. A
+// user the store doesn't hold is backfilled without the lock, so a slow
+// query never stalls other users' requests.
+func (s *recentStore) lockUser(userID string, now time.Time) *recentUser {
+	s.mu.Lock()
+	if user := s.heldLocked(userID, now); user != nil {
+		return user
+	}
+	s.mu.Unlock()
+
+	views := s.backfill(userID)
+
+	s.mu.Lock()
+	// Another request may have backfilled or recorded the user meanwhile,
+	// and its entry already holds everything stored plus newer views
+	if user := s.heldLocked(userID, now); user != nil {
+		return user
+	}
+	user := &recentUser{userID: userID, views: views, touched: now}
+	s.users[userID] = s.order.PushFront(user)
+
+	for s.order.Len() > 1 {
+		oldest := s.order.Back()
+		if s.order.Len() <= s.maxUsers && now.Sub(oldest.Value.(*recentUser).touched) <= s.ttl {
+			break
+		}
+		s.order.Remove(oldest)
+		delete(s.users, oldest.Value.(*recentUser).userID)
+	}
+	return user
+}
+
+// heldLocked returns the user's entry if the store holds it and it hasn't
+// idled out, marking it most recently used
+func (s *recentStore) heldLocked(userID string, now time.Time) *recentUser {
+	element, ok := s.users[userID]
+	if !ok {
+		return nil
+	}
+	user := element.Value.(*recentUser)
+	if now.Sub(user.touched) > s.ttl {
+		s.order.Remove(element)
+		delete(s.users, userID)
+		return nil
+	}
+	user.touched = now
+	s.order.MoveToFront(element)
+	return user
+}
+
+// loadRecentViews reads the user's latest product_view events from SQLite
+func loadRecentViews(userID string) []recentView {
+	if analyticsDB == nil {
+		return nil
+	}
+	rows, err := analyticsDB.Query(`
+		SELECT json_extract(data, '$.product_id') AS product_id, MAX(server_time) AS seen
+		FROM analytics_events
+		WHERE user_id = ? AND event_type = 'product_view' AND product_id IS NOT NULL
+		GROUP BY product_id
+		ORDER BY seen DESC
+		LIMIT ?`, userID, recentlyViewedCap)
+	if err != nil {
+		log.Printf("⚠️  Recently viewed backfill failed: %v", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var views []recentView
+	for rows.Next() {
+		var id, seen string
+		if rows.Scan(&id, &seen) == nil {
+			viewedAt, _ := time.Parse(sqliteTimeFormat, seen)
+			views = append(views, recentView{ProductID: id, ViewedAt: viewedAt})
+		}
+	}
+	return views
+}
+
+// recentlyViewedIDs returns the user's viewed product ids, newest first
+func recentlyViewedIDs(userID string) []string {
+	var ids []string
+	for _, view := range recentViews.forUser(userID) {
+		ids = append(ids, view.ProductID)
+	}
+	return ids
+}
+
+// recentlyViewedSection is the horizontal_list home builders add to show the
+// user's history. It is filled per request and dropped when empty.
+func recentlyViewedSection() map[string]interface{} {
+	return map[string]interface{}{
+		"id":   "recently-viewed",
+		"type": "horizontal_list",
+		"props": map[string]interface{}{
+			"title":     msg("home.recently_viewed"),
+			"height":    150.0,
+			"itemWidth": 120.0,
+			"data_source": map[string]interface{}{
+				"strategy": "recently_viewed",
+				"limit":    10,
+			},
+		},
+		"style": map[string]interface{}{
+			"padding": 16.0,
+			"spacing": 12.0,
+		},
+	}
+}
+
+// ==================== HANDLER ====================
+
+func handleRecentlyViewed(w http.ResponseWriter, r *http.Request) {
+	userID := currentUserID(r)
+
+	var products []interface{}
+	for _, view := range recentViews.forUser(userID) {
+		if product, ok := findProduct(view.ProductID); ok {
+			card := productCard(product, false)
+			card["viewed_at"] = view.ViewedAt.Format(time.RFC3339)
+			products = append(products, card)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"products": products,
+	})
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func viewedIDs(s *recentStore, userID string) []string {
+	var ids []string
+	for _, view := range s.forUser(userID) {
+		ids = append(ids, view.ProductID)
+	}
+	return ids
+}
+
+func TestRecentStoreRecord(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		viewed []string
+		want   []string
+	}{
+		{"newest first", []string{"a", "b", "c"}, []string{"c", "b", "a"}},
+		{"repeat view moves to front", []string{"a", "b", "a"}, []string{"a", "b"}},
+		{"empty product ignored", []string{"a", ""}, []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newRecentStore(10, time.Hour)
+			for i, id := range tt.viewed {
+				s.record("u", id, now.Add(time.Duration(i)*time.Second))
+			}
+			if got := viewedIDs(s, "u"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("viewed %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	s := newRecentStore(10, time.Hour)
+	for i := 0; i < recentlyViewedCap+5; i++ {
+		s.record("u", fmt.Sprintf("prod_%d", i), now)
+	}
+	if got := len(s.forUser("u")); got != recentlyViewedCap {
+		t.Errorf("kept %d views, want %d", got, recentlyViewedCap)
+	}
+}
+
+func TestRecentStoreEvictsUsers(t *testing.T) {
+	now := time.Now()
+	s := newRecentStore(2, time.Hour)
+	s.record("a", "prod_1", now)
+	s.record("b", "prod_1", now)
+	s.forUser("a") // a is now the most recently used
+	s.record("c", "prod_1", now)
+
+	for userID, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok := s.users[userID]; ok != want {
+			t.Errorf("holds %q = %v, want %v", userID, ok, want)
+		}
+	}
+
+	// Idle users expire even while the store has room
+	s.lockUser("d", now.Add(2*time.Hour))
+	s.mu.Unlock()
+	if s.order.Len() != 1 || len(s.users) != 1 {
+		t.Errorf("after idling: %d users listed, %d indexed, want 1", s.order.Len(), len(s.users))
+	}
+	if got := viewedIDs(s, "a"); got != nil {
+		t.Errorf("expired user kept views %v", got)
+	}
+}
+
+func TestRecentStoreMerge(t *testing.T) {
+	now := time.Now()
+	s := newRecentStore(10, time.Hour)
+	s.record("guest", "prod_1", now.Add(-2*time.Minute))
+	s.record("guest", "prod_2", now.Add(-time.Minute))
+	s.record("account", "prod_3", now)
+
+	s.merge("guest", "account")
+	if got, want := viewedIDs(s, "account"), []string{"prod_2", "prod_1", "prod_3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("merged %v, want %v", got, want)
+	}
+	if _, ok := s.users["guest"]; ok {
+		t.Error("guest history kept after merge")
+	}
+}
+
+// The backfill runs without the lock: other users are served meanwhile, and
+// a view recorded during the query survives it
+func TestRecentStoreBackfillUnlocked(t *testing.T) {
+	stored := []recentView{{ProductID: "prod_old"}}
+	release := make(chan struct{})
+	var calls atomic.Int32
+	s := newRecentStore(10, time.Hour)
+	s.backfill = func(userID string) []recentView {
+		if calls.Add(1) == 1 {
+			<-release
+		}
+		return append([]recentView(nil), stored...)
+	}
+
+	loaded := make(chan []string)
+	go func() { loaded <- viewedIDs(s, "slow") }()
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	s.record("slow", "prod_new", time.Now())
+	close(release)
+	want := []string{"prod_new", "prod_old"}
+	if got := <-loaded; !reflect.DeepEqual(got, want) {
+		t.Errorf("slow backfill returned %v, want %v", got, want)
+	}
+	if got := viewedIDs(s, "slow"); !reflect.DeepEqual(got, want) {
+		t.Errorf("stored %v, want %v", got, want)
+	}
+}
+
+func TestProductDetailRecordsView(t *testing.T) {
+	sink := &memorySink{}
+	previous := analytics
+	analytics = newAnalyticsPipeline(16, 100, time.Hour, sink)
+	t.Cleanup(func() { analytics = previous })
+
+	for _, id := range []string{"prod_1", "prod_missing"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/products/"+id, nil)
+		req = req.WithContext(context.WithValue(req.Context(), sessionKey{}, &Session{UserID: "guest_product_view"}))
+		handleProductDetail(httptest.NewRecorder(), req)
+	}
+	analytics.Close()
+
+	if len(sink.events) != 1 {
+		t.Fatalf("stored %d events, want 1", len(sink.events))
+	}
+	event := sink.events[0]
+	if event.Type != "product_view" || event.Data["product_id"] != "prod_1" || event.UserID != "guest_product_view" {
+		t.Errorf("stored %+v, want a product_view of prod_1", event)
+	}
+	if got := recentlyViewedIDs("guest_product_view"); !reflect.DeepEqual(got, []string{"prod_1"}) {
+		t.Errorf("recently viewed %v, want [prod_1]", got)
+	}
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ==================== STRATEGIES ====================
+
+const (
+	defaultRecommendationLimit = 6
+	trendingWindow             = 7 * 24 * time.Hour
+	trendingCacheTTL           = time.Minute
+	similarPriceBand           = 0.4 // ±40% of the seed product's price
+)
+
+// recommendationRequest is a component's data_source, resolved per user
+type recommendationRequest struct {
+	Strategy  string
+	Limit     int
+	ProductID string // seed for "similar"; defaults to the user's latest product
+	UserID    string
+}
+
+// recommendationStrategies maps data_source strategy names to resolvers
+var recommendationStrategies = map[string]func(recommendationRequest) []Product{
+	"trending":        recommendTrending,
+	"co_purchased":    recommendCoPurchased,
+	"recently_viewed": recommendRecentlyViewed,
+	"similar":         recommendSimilar,
+}
+
+// recommendTrending ranks products by weighted recent engagement across
+// all users (views 1, taps 1, add to cart 3, units ordered 5)
+func recommendTrending(req recommendationRequest) []Product {
+	scores := trending.scores()
+	return rankProducts(scores, nil, req.Limit)
+}
+
+// recommendCoPurchased ranks products that were bought in the same orders
+// as anything the user bought or has in their cart
+func recommendCoPurchased(req recommendationRequest) []Product {
+	owned := map[string]bool{}
+	for _, order := range userData.ordersOf(req.UserID) {
+		for _, item := range order.Items {
+			owned[item.ProductID] = true
+		}
+	}
+	for _, item := range userData.cart(req.UserID) {
+		owned[item.ProductID] = true
+	}
+	if len(owned) == 0 {
+		return nil
+	}
+
+	scores := map[string]float64{}
+	for _, order := range userData.allOrders() {
+		overlap := false
+		for _, item := range order.Items {
+			if owned[item.ProductID] {
+				overlap = true
+				break
+			}
+		}
+		if !overlap {
+			continue
+		}
+		for _, item := range order.Items {
+			scores[item.ProductID]++
+		}
+	}
+	return rankProducts(scores, owned, req.Limit)
+}
+
+// recommendRecentlyViewed returns the user's latest viewed products, newest first
+func recommendRecentlyViewed(req recommendationRequest) []Product {
+	var products []Product
+	for _, id := range recentlyViewedIDs(req.UserID) {
+		if product, ok := findProduct(id); ok {
+			products = append(products, product)
+		}
+		if len(products) == req.Limit {
+			break
+		}
+	}
+	return products
+}
+
+// recommendSimilar picks products in the seed's category within a price band,
+// closest price first
+func recommendSimilar(req recommendationRequest) []Product {
+	seedID := req.ProductID
+	if seedID == "" {
+		if recent := recentlyViewedIDs(req.UserID); len(recent) > 0 {
+			seedID = recent[0]
+		}
+	}
+	seed, ok := findProduct(seedID)
+	if !ok {
+		return nil
+	}
+
+	var candidates []Product
+	for _, p := range getAllProducts() {
+		if p.ID == seed.ID || p.Category != seed.Category {
+			continue
+		}
+		if math.Abs(p.Price-seed.Price) <= seed.Price*similarPriceBand {
+			candidates = append(candidates, p)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return math.Abs(candidates[i].Price-seed.Price) < math.Abs(candidates[j].Price-seed.Price)
+	})
+	if len(candidates) > req.Limit {
+		candidates = candidates[:req.Limit]
+	}
+	return candidates
+}
+
+// rankProducts orders catalog products by descending score, skipping excluded ids
+func rankProducts(scores map[string]float64, exclude map[string]bool, limit int) []Product {
+	var ranked []Product
+	for id, score := range scores {
+		if score <= 0 || exclude[id] {
+			continue
+		}
+		if product, ok := findProduct(id); ok {
+			ranked = append(ranked, product)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		si, sj := scores[ranked[i].ID], scores[ranked[j].ID]
+		if si != sj {
+			return si > sj
+		}
+		return ranked[i].ID < ranked[j].ID
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
+
+// findProduct looks a product up in the catalog without the placeholder fallback
+func findProduct(id string) (Product, bool) {
+	for _, p := range getAllProducts() {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return Product{}, false
+}
+
+// ==================== TRENDING CACHE ====================
+
+// trendingCache keeps the trending scores for trendingCacheTTL so every
+// home render doesn't rescan a week of analytics
+type trendingCache struct {
+	mu       sync.Mutex
+	computed time.Time
+	cached   map[string]float64
+}
+
+var trending = &trendingCache{}
+
+func (c *trendingCache) scores() map[string]float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cached != nil && time.Since(c.computed) < trendingCacheTTL {
+		return c.cached
+	}
+	since := time.Now().Add(-trendingWindow)
+	c.cached = queryTrendingScores(since)
+	for _, order := range userData.allOrders() {
+		if order.CreatedAt.Before(since) {
+			continue
+		}
+		for _, item := range order.Items {
+			c.cached[item.ProductID] += 5 * float64(item.Quantity)
+		}
+	}
+	c.computed = time.Now()
+	return c.cached
+}
+
+func queryTrendingScores(since time.Time) map[string]float64 {
+	scores := map[string]float64{}
+	if analyticsDB == nil {
+		return scores
+	}
+	rows, err := analyticsDB.Query(`
+		SELECT json_extract(data, '$.product_id') AS product_id,
+			SUM(CASE event_type WHEN 'add_to_cart' THEN 3 ELSE 1 END)
+		FROM analytics_events
+		WHERE server_time >= ? AND product_id IS NOT NULL
+			AND event_type IN ('product_view', 'tap', 'add_to_cart')
+		GROUP BY product_id`, since.UTC().Format(sqliteTimeFormat))
+	if err != nil {
+		log.Printf("⚠️  Trending lookup failed: %v", err)
+		return scores
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var score float64
+		if rows.Scan(&id, &score) == nil {
+			scores[id] = score
+		}
+	}
+	return scores
+}
+
+// ==================== LAYOUT DATA SOURCES ====================
+
+// productCard renders a catalog product in the shape product_grid and
+// product_carousel expect
+func productCard(p Product, favorite bool) map[string]interface{} {
+	card := map[string]interface{}{
+		"id":          p.ID,
+		"name":        p.Name,
+		"price":       p.Price,
+		"image_url":   p.ImageURL,
+		"is_favorite": favorite,
+	}
+	if p.Discount != nil {
+		card["discount"] = *p.Discount
+		card["original_price"] = math.Round(p.Price/(1-float64(*p.Discount)/100)*100) / 100
+	}
+	return card
+}
+
+// resolveDataSources fills product lists declared as
+//
+//	"data_source": {"strategy": "trending", "limit": 6, "product_id": "prod_1"}
+//
+// with recommendations for the current user. Any static "products" list is
+// kept as the fallback when a strategy has nothing to offer yet; a
+// component left with no products at all is dropped.
+func resolveDataSources(components []interface{}, ctx RenderContext) []interface{} {
+	kept := make([]interface{}, 0, len(components))
+	for _, c := range components {
+		component, ok := c.(map[string]interface{})
+		if !ok {
+			kept = append(kept, c)
+			continue
+		}
+		if props, ok := component["props"].(map[string]interface{}); ok {
+			if source, ok := props["data_source"].(map[string]interface{}); ok {
+				if err := resolveDataSource(component, source, ctx); err != nil {
+					log.Printf("⚠️  Data source for %v: %v", component["id"], err)
+				}
+				delete(props, "data_source")
+				if props["products"] == nil && props["items"] == nil {
+					continue
+				}
+			}
+		}
+		if children, ok := component["children"].([]interface{}); ok {
+			component["children"] = resolveDataSources(children, ctx)
+		}
+		kept = append(kept, component)
+	}
+	return kept
+}
+
+func resolveDataSource(component, source map[string]interface{}, ctx RenderContext) error {
+	strategy, _ := source["strategy"].(string)
+	resolve, ok := recommendationStrategies[strategy]
+	if !ok {
+		return fmt.Errorf("unknown strategy %q", strategy)
+	}
+
+	req := recommendationRequest{Strategy: strategy, Limit: defaultRecommendationLimit, UserID: ctx.UserID}
+	if limit, ok := source["limit"].(int); ok && limit > 0 {
+		req.Limit = limit
+	}
+	if limit, ok := source["limit"].(float64); ok && limit > 0 {
+		req.Limit = int(limit)
+	}
+	req.ProductID, _ = source["product_id"].(string)
+
+	products := resolve(req)
+	if len(products) == 0 {
+		return nil
+	}
+
+	props := component["props"].(map[string]interface{})
+	props["recommendation_strategy"] = strategy
+
+	// horizontal_list renders plain image tiles rather than product cards
+	if component["type"] == "horizontal_list" {
+		items := make([]interface{}, len(products))
+		for i, p := range products {
+			items[i] = map[string]interface{}{
+				"id":        p.ID,
+				"title":     p.Name,
+				"image_url": p.ImageURL,
+			}
+		}
+		props["items"] = items
+		return nil
+	}
+
+	favorites := userData.favoritesOf(ctx.UserID)
+	cards := make([]interface{}, len(products))
+	for i, p := range products {
+		cards[i] = productCard(p, slices.Contains(favorites, p.ID))
+	}
+	props["products"] = cards
+	return nil
+}
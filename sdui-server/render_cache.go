@@ -0,0 +1,486 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==================== RENDER CACHE ====================
+
+// A ui-config is a layout (the same for everyone sharing a renderKey) with
+// a few per-request fragments: templates naming the shopper or the clock,
+// data-sourced product lists, countdowns and the metadata timestamps. The
+// cache keeps each layout pre-encoded, with slots where those fragments go,
+// so a hit only renders the slots and splices bytes.
+
+const (
+	renderCacheMaxEntries = 512
+	// Entries expire at the next mode boundary, or sooner so time_window
+	// visibility rules are never more than this late
+	renderCacheTTL = time.Minute
+)
+
+// renderKey is everything the layout stage of handleUiConfig depends on
+type renderKey struct {
+	Screen        string
+	ProductID     string
+	Mode          string
+	Locale        string
+	Variant       string // experiment assignments
+	Accessibility AccessibilityPrefs
+	Platform      string
+	Flags         string // evaluated flag values
+	Segments      string
+	LoggedIn      bool
+	CartFilled    bool
+}
+
+func newRenderKey(screen, mode string, ctx RenderContext, assignments map[string]string) renderKey {
+	flagValues, _ := json.Marshal(flags.evaluateAll(ctx.Flags))
+	userSegments := append([]string(nil), ctx.Segments...)
+	sort.Strings(userSegments)
+	return renderKey{
+		Screen:        screen,
+		ProductID:     ctx.ProductID,
+		Mode:          mode,
+		Locale:        ctx.Locale,
+		Variant:       formatAssignments(assignments),
+		Accessibility: ctx.Accessibility,
+		Platform:      ctx.Flags.Platform,
+		Flags:         string(flagValues),
+		Segments:      strings.Join(userSegments, ","),
+		LoggedIn:      ctx.LoggedIn,
+		CartFilled:    ctx.CartCount > 0,
+	}
+}
+
+type renderCacheStore struct {
+	mu      sync.RWMutex
+	enabled bool
+	entries map[renderKey]*renderEntry
+}
+
+var renderCache = &renderCacheStore{
+	enabled: os.Getenv("SDUI_RENDER_CACHE") != "off",
+	entries: make(map[renderKey]*renderEntry),
+}
+
+func (c *renderCacheStore) get(key renderKey, now time.Time) (*renderEntry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.enabled {
+		return nil, false
+	}
+	entry, ok := c.entries[key]
+	if !ok || !now.Before(entry.expires) {
+		return nil, false
+	}
+	return entry, true
+}
+
+func (c *renderCacheStore) put(key renderKey, entry *renderEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.enabled {
+		return
+	}
+	if len(c.entries) >= renderCacheMaxEntries {
+		c.evictLocked(entry.created)
+	}
+	c.entries[key] = entry
+}
+
+// evictLocked drops expired entries, and the oldest one if that's not enough
+func (c *renderCacheStore) evictLocked(now time.Time) {
+	var oldestKey renderKey
+	var oldest *renderEntry
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+			continue
+		}
+		if oldest == nil || entry.created.Before(oldest.created) {
+			oldestKey, oldest = key, entry
+		}
+	}
+	if len(c.entries) >= renderCacheMaxEntries && oldest != nil {
+		delete(c.entries, oldestKey)
+	}
+}
+
+// invalidate drops the entries matching match (all of them when nil)
+func (c *renderCacheStore) invalidate(reason string, match func(renderKey) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dropped := 0
+	for key := range c.entries {
+		if match == nil || match(key) {
+			delete(c.entries, key)
+			dropped++
+		}
+	}
+	if dropped > 0 {
+		log.Printf("🧹 Render cache: dropped %d entries (%s)", dropped, reason)
+	}
+}
+
+// ==================== BUILDING ENTRIES ====================
+
+// dynamicText marks a layout string whose templates need per-request values
+type dynamicText string
+
+// renderEntry is a compiled layout: encoded JSON split around slots
+type renderEntry struct {
+	parts   []renderPart
+	created time.Time
+	expires time.Time
+
+	compressed compressedCache
+}
+
+// buildRenderEntry runs the layout stage of the pipeline and compiles the
+// result. Templates that only need layout values are rendered now; the
+// problems list covers every template, including per-request ones.
+func buildRenderEntry(screen, mode string, ctx RenderContext) *renderEntry {
+	config := buildScreenConfig(screen, mode, ctx)
+	applyExperiments(config, screen, mode, ctx.UserID)
+	if components, ok := config["components"].([]interface{}); ok {
+		config["components"] = applyVisibility(components, ctx)
+	}
+	resolveThemeTokens(config)
+	applyAccessibility(config, ctx.Accessibility)
+	applyTextDirection(config, ctx.Locale)
+	// Stamp before filling countdowns and per-user product lists so the
+	// revision identifies the layout, not one request's clock or one
+	// shopper's recommendations
+	stampLayout(config, screen, mode)
+
+	layout := cloneJSONMap(config)
+	problems := applyLayoutTemplates(layout, ctx)
+	if metadata, ok := layout["metadata"].(map[string]interface{}); ok {
+		metadata["locale"] = ctx.Locale
+		metadata["config_revision"] = ""
+		if len(problems) > 0 {
+			metadata["template_errors"] = problems
+		}
+	}
+
+	_, modeEnd := currentModeWindow(ctx.Now)
+	return &renderEntry{
+		parts:   compileValue(layout, nil, "", nil),
+		created: ctx.Now,
+		expires: minTime(ctx.Now.Add(renderCacheTTL), modeEnd),
+	}
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// applyLayoutTemplates renders the templates that only use layout values
+// and marks the rest as dynamicText for per-request rendering
+func applyLayoutTemplates(config map[string]interface{}, ctx RenderContext) []string {
+	layoutVars := layoutTemplateVars(ctx)
+	allVars := templateVars(ctx)
+	var problems []string
+	var walk func(value interface{}, path string) interface{}
+	walk = func(value interface{}, path string) interface{} {
+		switch v := value.(type) {
+		case string:
+			if !strings.Contains(v, "{{") {
+				return v
+			}
+			if rendered, err := renderTemplate(v, layoutVars); err == nil {
+				return rendered
+			}
+			if _, err := renderTemplate(v, allVars); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", path, err))
+			}
+			return dynamicText(v)
+		case map[string]interface{}:
+			for key, child := range v {
+				childPath := path + "." + key
+				if id, ok := v["id"].(string); ok && key != "id" {
+					childPath = path + "[" + id + "]." + key
+				}
+				v[key] = walk(child, childPath)
+			}
+		case []interface{}:
+			for i, child := range v {
+				v[i] = walk(child, path)
+			}
+		}
+		return value
+	}
+	for _, key := range []string{"components", "navigation"} {
+		if value, ok := config[key]; ok {
+			config[key] = walk(value, key)
+		}
+	}
+	for _, problem := range problems {
+		log.Printf("⚠️  Template error: %s", problem)
+	}
+	return problems
+}
+
+// ==================== COMPILING ====================
+
+type slotKind int
+
+const (
+	slotTemplate  slotKind = iota // a dynamicText string
+	slotComponent                 // a countdown or data-sourced component, possibly dropped
+	slotList                      // an array holding slotComponent elements
+	slotClock                     // a metadata timestamp
+	slotRevision                  // metadata.config_revision
+)
+
+// renderPart is either literal bytes or a slot filled per request
+type renderPart struct {
+	literal []byte
+	kind    slotKind
+	text    string                 // slotTemplate source, slotClock time layout
+	value   map[string]interface{} // slotComponent
+	items   [][]renderPart         // slotList elements
+}
+
+// compileValue encodes value the way encoding/json would (maps with sorted
+// keys), appending to parts and opening slots for per-request fragments
+func compileValue(value interface{}, parts []renderPart, path string, buf *bytes.Buffer) []renderPart {
+	if buf == nil {
+		buf = &bytes.Buffer{}
+		parts = compileValue(value, parts, path, buf)
+		return flushLiteral(parts, buf)
+	}
+
+	switch v := value.(type) {
+	case dynamicText:
+		parts = flushLiteral(parts, buf)
+		return append(parts, renderPart{kind: slotTemplate, text: string(v)})
+	case string:
+		switch path {
+		case "/metadata/timestamp":
+			parts = flushLiteral(parts, buf)
+			return append(parts, renderPart{kind: slotClock, text: time.RFC3339})
+		case "/metadata/server_time":
+			parts = flushLiteral(parts, buf)
+			return append(parts, renderPart{kind: slotClock, text: "3:04 PM"})
+		case "/metadata/config_revision":
+			parts = flushLiteral(parts, buf)
+			return append(parts, renderPart{kind: slotRevision})
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			encodedKey, _ := json.Marshal(key)
+			buf.Write(encodedKey)
+			buf.WriteByte(':')
+			parts = compileValue(v[key], parts, path+"/"+key, buf)
+		}
+		buf.WriteByte('}')
+		return parts
+	case []interface{}:
+		if !hasPerRequestComponent(v) {
+			buf.WriteByte('[')
+			for i, item := range v {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				parts = compileValue(item, parts, path+"/-", buf)
+			}
+			buf.WriteByte(']')
+			return parts
+		}
+		list := renderPart{kind: slotList}
+		for _, item := range v {
+			if component, ok := item.(map[string]interface{}); ok && isPerRequestComponent(component) {
+				list.items = append(list.items, []renderPart{{kind: slotComponent, value: component}})
+				continue
+			}
+			list.items = append(list.items, compileValue(item, nil, path+"/-", nil))
+		}
+		parts = flushLiteral(parts, buf)
+		return append(parts, list)
+	}
+
+	encoded, _ := json.Marshal(value)
+	buf.Write(encoded)
+	return parts
+}
+
+func flushLiteral(parts []renderPart, buf *bytes.Buffer) []renderPart {
+	if buf.Len() == 0 {
+		return parts
+	}
+	parts = append(parts, renderPart{literal: bytes.Clone(buf.Bytes())})
+	buf.Reset()
+	return parts
+}
+
+// isPerRequestComponent reports whether a component is filled (or dropped)
+// per request: countdowns and anything with a data_source
+func isPerRequestComponent(component map[string]interface{}) bool {
+	if component["type"] == "countdown_timer" {
+		return true
+	}
+	props, _ := component["props"].(map[string]interface{})
+	_, sourced := props["data_source"]
+	return sourced
+}
+
+func hasPerRequestComponent(items []interface{}) bool {
+	for _, item := range items {
+		if component, ok := item.(map[string]interface{}); ok && isPerRequestComponent(component) {
+			return true
+		}
+	}
+	return false
+}
+
+// ==================== RENDERING ENTRIES ====================
+
+const revisionPlaceholder = "cfg_0000000000000000"
+
+// entryRender writes a request's document, plus a "stable" copy with the
+// clock readings blanked that the config revision is hashed from
+type entryRender struct {
+	ctx            RenderContext
+	vars           map[string]interface{}
+	full, stable   bytes.Buffer
+	revisionOffset int
+}
+
+// render fills the entry's slots for a request and returns the document
+// and its config revision
+func (e *renderEntry) render(ctx RenderContext) ([]byte, string) {
+	rr := &entryRender{ctx: ctx, vars: templateVars(ctx), revisionOffset: -1}
+	rr.writeParts(e.parts)
+	rr.full.WriteByte('\n')
+
+	sum := sha256.Sum256(rr.stable.Bytes())
+	revision := "cfg_" + hex.EncodeToString(sum[:8])
+	body := rr.full.Bytes()
+	if rr.revisionOffset >= 0 {
+		copy(body[rr.revisionOffset:], revision)
+	}
+	return body, revision
+}
+
+func (rr *entryRender) write(full, stable []byte) {
+	rr.full.Write(full)
+	rr.stable.Write(stable)
+}
+
+func (rr *entryRender) writeParts(parts []renderPart) {
+	for _, part := range parts {
+		if part.literal != nil {
+			rr.write(part.literal, part.literal)
+			continue
+		}
+		switch part.kind {
+		case slotTemplate:
+			rendered, err := renderTemplate(part.text, rr.vars)
+			if err != nil {
+				log.Printf("⚠️  Template error: %v", err)
+			}
+			encoded, _ := json.Marshal(rendered)
+			rr.write(encoded, encoded)
+		case slotClock:
+			encoded, _ := json.Marshal(rr.ctx.Now.Format(part.text))
+			rr.write(encoded, []byte(`""`))
+		case slotRevision:
+			rr.full.WriteByte('"')
+			rr.revisionOffset = rr.full.Len()
+			rr.full.WriteString(revisionPlaceholder)
+			rr.full.WriteByte('"')
+			rr.stable.WriteString(`""`)
+		case slotList:
+			rr.writeList(part.items)
+		}
+	}
+}
+
+func (rr *entryRender) writeList(items [][]renderPart) {
+	rr.write([]byte{'['}, []byte{'['})
+	written := 0
+	for _, item := range items {
+		if len(item) == 1 && item[0].kind == slotComponent && item[0].literal == nil {
+			full, stable, ok := rr.renderComponent(item[0].value)
+			if !ok {
+				continue
+			}
+			if written > 0 {
+				rr.write([]byte{','}, []byte{','})
+			}
+			rr.write(full, stable)
+			written++
+			continue
+		}
+		if written > 0 {
+			rr.write([]byte{','}, []byte{','})
+		}
+		rr.writeParts(item)
+		written++
+	}
+	rr.write([]byte{']'}, []byte{']'})
+}
+
+// renderComponent runs the per-request passes over a copy of a component;
+// false means the passes dropped it
+func (rr *entryRender) renderComponent(component map[string]interface{}) ([]byte, []byte, bool) {
+	components := []interface{}{cloneJSONMap(component)}
+	var problems []string
+	renderTemplateValue(components, "components", rr.vars, &problems)
+	for _, problem := range problems {
+		log.Printf("⚠️  Template error: %s", problem)
+	}
+	components = resolveCountdowns(components, rr.ctx)
+	components = resolveDataSources(components, rr.ctx)
+	if len(components) == 0 {
+		return nil, nil, false
+	}
+
+	full, _ := json.Marshal(components[0])
+	if !bytes.Contains(full, []byte(`"server_time"`)) {
+		return full, full, true
+	}
+	stripServerTimes(components)
+	stable, _ := json.Marshal(components[0])
+	return full, stable, true
+}
+
+// stripServerTimes drops the per-request clock countdowns carry
+func stripServerTimes(components []interface{}) {
+	for _, c := range components {
+		component, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if props, ok := component["props"].(map[string]interface{}); ok {
+			delete(props, "server_time")
+		}
+		if children, ok := component["children"].([]interface{}); ok {
+			stripServerTimes(children)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+// Every spelling of a screen renders that screen, and a cached entry built
+// for one spelling is only ever the right layout for the others
+func TestUiConfigScreenSpellings(t *testing.T) {
+	quietLogs(t)
+	renderCache.mu.Lock()
+	previousEnabled, previousEntries := renderCache.enabled, renderCache.entries
+	renderCache.enabled, renderCache.entries = true, make(map[renderKey]*renderEntry)
+	renderCache.mu.Unlock()
+	t.Cleanup(func() {
+		renderCache.mu.Lock()
+		renderCache.enabled, renderCache.entries = previousEnabled, previousEntries
+		renderCache.mu.Unlock()
+	})
+
+	tests := []struct {
+		screen   string
+		route    string
+		wantID   string
+		wantHits string
+	}{
+		{"cart", "/cart", "cart-header", "miss"},
+		{"/cart", "/cart", "cart-header", "hit"},
+		{"/cart/", "/cart", "cart-header", "hit"},
+		{"search", "/search", "search-input", "miss"},
+		{"/search", "/search", "search-input", "hit"},
+		{"home", "/", "", "miss"},
+		{"/", "/", "", "hit"},
+	}
+	for _, tt := range tests {
+		rec := getUiConfig(t, "/api/ui-config?locale=en&screen="+tt.screen, nil)
+		if got := rec.Header().Get("X-Render-Cache"); got != tt.wantHits {
+			t.Errorf("screen %q: render cache %s, want %s", tt.screen, got, tt.wantHits)
+		}
+		var config map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &config); err != nil {
+			t.Fatalf("screen %q: %v", tt.screen, err)
+		}
+		ids := componentIDs(config)
+		if tt.wantID != "" && !slices.Contains(ids, tt.wantID) {
+			t.Errorf("screen %q: components %v, want %s", tt.screen, ids, tt.wantID)
+		}
+		if tt.wantID == "" && slices.Contains(ids, "cart-header") {
+			t.Errorf("screen %q rendered the cart", tt.screen)
+		}
+		first := config["components"].([]interface{})[0].(map[string]interface{})
+		token, _ := first["tracking_token"].(string)
+		if attribution, ok := decodeTrackingToken(token); !ok || attribution.Screen != tt.route {
+			t.Errorf("screen %q: tracking token screen %q, want %q", tt.screen, attribution.Screen, tt.route)
+		}
+	}
+}
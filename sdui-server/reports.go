@@ -0,0 +1,246 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ==================== REPORT QUERIES ====================
+
+// analyticsDB is the SQLite sink's handle, nil when the sink is disabled
+var analyticsDB *sql.DB
+
+// reportGroupings maps a group_by value onto the SQL expression it groups by.
+// Mode prefers the mode the layout was served in over the mode at ingestion.
+// Hours are local like the mode windows, while server_time is stored in UTC.
+var reportGroupings = map[string]string{
+	"mode":    "COALESCE(layout_mode, mode)",
+	"hour":    "strftime('%H', server_time, 'localtime')",
+	"day":     "substr(server_time, 1, 10)",
+	"screen":  "COALESCE(screen, '')",
+	"variant": "COALESCE(variant, 'none')",
+}
+
+// reportFilter is the WHERE clause shared by every report
+type reportFilter struct {
+	From   time.Time
+	To     time.Time
+	Screen string
+	Mode   string
+}
+
+func parseReportFilter(r *http.Request) (reportFilter, error) {
+	query := r.URL.Query()
+	filter := reportFilter{
+		Screen: query.Get("screen"),
+		Mode:   query.Get("mode"),
+	}
+
+	var err error
+	if from := query.Get("from"); from != "" {
+		if filter.From, err = parseReportTime(from, false); err != nil {
+			return filter, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+	if to := query.Get("to"); to != "" {
+		if filter.To, err = parseReportTime(to, true); err != nil {
+			return filter, fmt.Errorf("invalid to: %w", err)
+		}
+	}
+	return filter, nil
+}
+
+// parseReportTime accepts RFC 3339 timestamps or plain dates. A plain "to"
+// date covers the whole day.
+func parseReportTime(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
+}
+
+func (f reportFilter) where() (string, []interface{}) {
+	clauses := []string{"1 = 1"}
+	var args []interface{}
+
+	if !f.From.IsZero() {
+		clauses = append(clauses, "server_time >= ?")
+		args = append(args, f.From.Format(sqliteTimeFormat))
+	}
+	if !f.To.IsZero() {
+		clauses = append(clauses, "server_time < ?")
+		args = append(args, f.To.Format(sqliteTimeFormat))
+	}
+	if f.Screen != "" {
+		clauses = append(clauses, "screen = ?")
+		args = append(args, f.Screen)
+	}
+	if f.Mode != "" {
+		clauses = append(clauses, "COALESCE(layout_mode, mode) = ?")
+		args = append(args, f.Mode)
+	}
+	return strings.Join(clauses, " AND "), args
+}
+
+// overviewReport counts funnel events per group and derives rates from them
+func overviewReport(groupBy string, filter reportFilter) ([]map[string]interface{}, error) {
+	where, args := filter.where()
+	query := fmt.Sprintf(`
+		SELECT %s AS grp,
+			SUM(event_type = 'screen_view'),
+			SUM(event_type = 'impression'),
+			SUM(event_type = 'tap'),
+			SUM(event_type = 'add_to_cart'),
+			SUM(event_type = 'purchase'),
+			COUNT(DISTINCT NULLIF(user_id, ''))
+		FROM analytics_events
+		WHERE %s
+		GROUP BY grp
+		ORDER BY grp`, reportGroupings[groupBy], where)
+
+	rows, err := analyticsDB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := []map[string]interface{}{}
+	for rows.Next() {
+		var group string
+		var views, impressions, taps, carts, purchases, users int64
+		if err := rows.Scan(&group, &views, &impressions, &taps, &carts, &purchases, &users); err != nil {
+			return nil, err
+		}
+		report = append(report, map[string]interface{}{
+			groupBy:            group,
+			"screen_views":     views,
+			"impressions":      impressions,
+			"taps":             taps,
+			"add_to_carts":     carts,
+			"purchases":        purchases,
+			"unique_users":     users,
+			"ctr":              ratio(taps, impressions),
+			"add_to_cart_rate": ratio(carts, views),
+			"conversion_rate":  ratio(purchases, views),
+		})
+	}
+	return report, rows.Err()
+}
+
+// componentReport computes CTR per component, keyed by the layout that served it
+func componentReport(filter reportFilter) ([]map[string]interface{}, error) {
+	where, args := filter.where()
+	query := fmt.Sprintf(`
+		SELECT COALESCE(screen, ''), COALESCE(layout_mode, mode), COALESCE(layout_revision, ''), component_id,
+			SUM(event_type = 'impression'),
+			SUM(event_type = 'tap'),
+			SUM(event_type = 'add_to_cart')
+		FROM analytics_events
+		WHERE %s AND component_id IS NOT NULL
+		GROUP BY 1, 2, 3, 4
+		ORDER BY 1, 2, 3, 4`, where)
+
+	rows, err := analyticsDB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := []map[string]interface{}{}
+	for rows.Next() {
+		var screen, mode, revision, componentID string
+		var impressions, taps, carts int64
+		if err := rows.Scan(&screen, &mode, &revision, &componentID, &impressions, &taps, &carts); err != nil {
+			return nil, err
+		}
+		report = append(report, map[string]interface{}{
+			"screen":          screen,
+			"mode":            mode,
+			"layout_revision": revision,
+			"component_id":    componentID,
+			"impressions":     impressions,
+			"taps":            taps,
+			"add_to_carts":    carts,
+			"ctr":             ratio(taps, impressions),
+		})
+	}
+	return report, rows.Err()
+}
+
+func ratio(numerator, denominator int64) float64 {
+	if denominator == 0 {
+		return 0
+	}
+	return float64(numerator) / float64(denominator)
+}
+
+// ==================== REPORT HANDLER ====================
+
+// handleReports serves:
+//
+//	GET /api/reports/overview?group_by=mode|hour|day|screen|variant
+//	GET /api/reports/components
+//
+// Both accept from, to (RFC 3339 or YYYY-MM-DD), screen and mode filters.
+func handleReports(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if analyticsDB == nil {
+		http.Error(w, "Reporting unavailable: SQLite analytics sink is disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	filter, err := parseReportFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var report []map[string]interface{}
+	name := strings.TrimPrefix(r.URL.Path, "/api/reports/")
+	if name == "" {
+		name = "overview"
+	}
+
+	switch name {
+	case "overview":
+		groupBy := r.URL.Query().Get("group_by")
+		if groupBy == "" {
+			groupBy = "mode"
+		}
+		if _, ok := reportGroupings[groupBy]; !ok {
+			http.Error(w, fmt.Sprintf("unsupported group_by %q", groupBy), http.StatusBadRequest)
+			return
+		}
+		report, err = overviewReport(groupBy, filter)
+	case "components":
+		report, err = componentReport(filter)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"report":       name,
+		"generated_at": time.Now().Format(time.RFC3339),
+		"rows":         report,
+	})
+}
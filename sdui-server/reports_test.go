@@ -0,0 +1,32 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Hourly reports bucket by the server's local clock, like the mode windows
+func TestOverviewReportLocalHours(t *testing.T) {
+	sink, err := newSQLiteSink(filepath.Join(t.TempDir(), "analytics.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sink.Close() })
+	previous := analyticsDB
+	analyticsDB = sink.db
+	t.Cleanup(func() { analyticsDB = previous })
+
+	at := time.Date(2025, 6, 1, 23, 30, 0, 0, time.UTC)
+	if err := sink.WriteBatch([]AnalyticsEvent{{ID: "evt_1", Type: "screen_view", ServerTime: at, Mode: "night"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := overviewReport("hour", reportFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := at.Local().Format("15"); len(report) != 1 || report[0]["hour"] != want {
+		t.Errorf("report = %v, want one row for hour %s", report, want)
+	}
+}
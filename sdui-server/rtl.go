@@ -0,0 +1,114 @@
+package main
+
+// ==================== RIGHT-TO-LEFT LAYOUTS ====================
+
+// Layouts are authored left-to-right. For RTL locales the server mirrors
+// direction-sensitive props itself, so clients that lay out with absolute
+// alignments render the right way round without a second set of layouts.
+
+var rtlLanguages = map[string]bool{
+	"ar": true,
+	"he": true,
+	"fa": true,
+	"ur": true,
+}
+
+func isRTL(locale string) bool {
+	return rtlLanguages[baseLanguage(locale)]
+}
+
+func textDirection(locale string) string {
+	if isRTL(locale) {
+		return "rtl"
+	}
+	return "ltr"
+}
+
+// mirroredValues swaps each direction-sensitive value for its mirror image
+var mirroredValues = map[string]string{
+	"left":          "right",
+	"right":         "left",
+	"centerLeft":    "centerRight",
+	"centerRight":   "centerLeft",
+	"topLeft":       "topRight",
+	"topRight":      "topLeft",
+	"bottomLeft":    "bottomRight",
+	"bottomRight":   "bottomLeft",
+	"arrow_forward": "arrow_back",
+	"arrow_back":    "arrow_forward",
+	"chevron_right": "chevron_left",
+	"chevron_left":  "chevron_right",
+}
+
+// mirroredProps are the props and style keys whose values get mirrored
+var mirroredProps = []string{"alignment", "textAlign", "icon", "trailingIcon", "leadingIcon"}
+
+// mirroredSides swaps one-sided spacing so insets follow the text
+var mirroredSides = map[string]string{
+	"paddingLeft":  "paddingRight",
+	"paddingRight": "paddingLeft",
+	"marginLeft":   "marginRight",
+	"marginRight":  "marginLeft",
+}
+
+// applyTextDirection stamps the envelope with the locale's text direction
+// and mirrors the layout when it is right-to-left
+func applyTextDirection(config map[string]interface{}, locale string) {
+	direction := textDirection(locale)
+	config["text_direction"] = direction
+	if direction != "rtl" {
+		return
+	}
+	if components, ok := config["components"].([]interface{}); ok {
+		mirrorComponents(components)
+	}
+}
+
+func mirrorComponents(components []interface{}) {
+	for _, c := range components {
+		component, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, key := range []string{"props", "style"} {
+			if values, ok := component[key].(map[string]interface{}); ok {
+				mirrorValues(values)
+			}
+		}
+
+		children, ok := component["children"].([]interface{})
+		if !ok {
+			continue
+		}
+		mirrorComponents(children)
+		// Rows read start-to-end, so their children run the other way
+		if component["type"] == "row" {
+			reversed := make([]interface{}, len(children))
+			for i, child := range children {
+				reversed[len(children)-1-i] = child
+			}
+			component["children"] = reversed
+		}
+	}
+}
+
+func mirrorValues(values map[string]interface{}) {
+	for _, key := range mirroredProps {
+		if value, ok := values[key].(string); ok {
+			if mirrored, ok := mirroredValues[value]; ok {
+				values[key] = mirrored
+			}
+		}
+	}
+
+	swapped := map[string]interface{}{}
+	for key, mirror := range mirroredSides {
+		if value, ok := values[key]; ok {
+			swapped[mirror] = value
+			delete(values, key)
+		}
+	}
+	for key, value := range swapped {
+		values[key] = value
+	}
+}
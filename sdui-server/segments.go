@@ -0,0 +1,172 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ==================== SEGMENT DEFINITIONS ====================
+
+const (
+	segmentNewUser        = "new_user"
+	segmentReturningBuyer = "returning_buyer"
+	segmentHighSpender    = "high_spender"
+	segmentLapsed         = "lapsed"
+	segmentFavoritesHeavy = "favorites_heavy"
+)
+
+const (
+	newUserWindow      = 7 * 24 * time.Hour
+	lapsedAfter        = 30 * 24 * time.Hour
+	highSpenderTotal   = 1000.0
+	favoritesHeavyMin  = 5
+	segmentCacheTTL    = 5 * time.Minute
+	segmentCacheMaxLen = 10_000
+)
+
+// segmentSignals is everything segment rules look at for one user
+type segmentSignals struct {
+	AccountCreated time.Time
+	Orders         int
+	TotalSpend     float64
+	LastOrder      time.Time
+	Favorites      int
+	FirstSeen      time.Time
+}
+
+func collectSignals(userID string) segmentSignals {
+	var signals segmentSignals
+
+	if user, ok := users.get(userID); ok {
+		signals.AccountCreated = user.CreatedAt
+	}
+	for _, order := range userData.ordersOf(userID) {
+		signals.Orders++
+		signals.TotalSpend += order.Total
+		if order.CreatedAt.After(signals.LastOrder) {
+			signals.LastOrder = order.CreatedAt
+		}
+	}
+	signals.Favorites = len(userData.favoritesOf(userID))
+	signals.FirstSeen = analyticsFirstSeen(userID)
+	return signals
+}
+
+// analyticsFirstSeen returns when the user's first stored event happened
+func analyticsFirstSeen(userID string) time.Time {
+	if analyticsDB == nil {
+		return time.Time{}
+	}
+	var minTime sql.NullString
+	err := analyticsDB.QueryRow(
+		`SELECT MIN(server_time) FROM analytics_events WHERE user_id = ?`, userID,
+	).Scan(&minTime)
+	if err != nil {
+		return time.Time{}
+	}
+	first, _ := time.Parse(sqliteTimeFormat, minTime.String)
+	return first
+}
+
+// computeSegments applies the segment rules to a user's signals
+func computeSegments(s segmentSignals, now time.Time) []string {
+	result := []string{}
+
+	// Earliest sign of the user: account creation, else first tracked event
+	joined := s.AccountCreated
+	if joined.IsZero() || (!s.FirstSeen.IsZero() && s.FirstSeen.Before(joined)) {
+		joined = s.FirstSeen
+	}
+	if s.Orders == 0 && (joined.IsZero() || now.Sub(joined) < newUserWindow) {
+		result = append(result, segmentNewUser)
+	}
+	if s.Orders > 0 {
+		result = append(result, segmentReturningBuyer)
+	}
+	if s.TotalSpend >= highSpenderTotal {
+		result = append(result, segmentHighSpender)
+	}
+	// Lapsed buyers are judged on purchases alone: anyone we're rendering a
+	// layout for is browsing right now, and every fetch logs events for them
+	if s.Orders > 0 && now.Sub(s.LastOrder) > lapsedAfter {
+		result = append(result, segmentLapsed)
+	}
+	if s.Favorites >= favoritesHeavyMin {
+		result = append(result, segmentFavoritesHeavy)
+	}
+	return result
+}
+
+// ==================== SEGMENT CACHE ====================
+
+type cachedSegments struct {
+	segments []string
+	expires  time.Time
+}
+
+// segmentStore memoizes segments per user for segmentCacheTTL, since
+// computing them hits the analytics database
+type segmentStore struct {
+	mu      sync.Mutex
+	entries map[string]cachedSegments
+}
+
+var segments = &segmentStore{entries: make(map[string]cachedSegments)}
+
+// forUser returns the user's segments; anonymous users have none
+func (s *segmentStore) forUser(userID string) []string {
+	if userID == "" {
+		return []string{}
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	entry, ok := s.entries[userID]
+	s.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.segments
+	}
+
+	computed := computeSegments(collectSignals(userID), now)
+
+	s.mu.Lock()
+	if len(s.entries) >= segmentCacheMaxLen {
+		s.entries = make(map[string]cachedSegments)
+	}
+	s.entries[userID] = cachedSegments{segments: computed, expires: now.Add(segmentCacheTTL)}
+	s.mu.Unlock()
+	return computed
+}
+
+// invalidate drops a user's cached segments after something they depend on changed
+func (s *segmentStore) invalidate(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, userID)
+}
+
+func inAnySegment(have, wanted []string) bool {
+	for _, w := range wanted {
+		for _, h := range have {
+			if h == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ==================== HANDLER ====================
+
+func handleMySegments(w http.ResponseWriter, r *http.Request) {
+	userID := currentUserID(r)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user_id":  userID,
+		"segments": segments.forUser(userID),
+	})
+}
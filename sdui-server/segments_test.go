@@ -0,0 +1,53 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestComputeSegments(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time { return now.Add(-time.Duration(days) * 24 * time.Hour) }
+
+	tests := []struct {
+		name    string
+		signals segmentSignals
+		want    []string
+	}{
+		{"unknown user", segmentSignals{}, []string{segmentNewUser}},
+		{"recent account", segmentSignals{AccountCreated: daysAgo(2)}, []string{segmentNewUser}},
+		{"old account without orders", segmentSignals{AccountCreated: daysAgo(20)}, []string{}},
+		{"tracked before signing up", segmentSignals{AccountCreated: daysAgo(1), FirstSeen: daysAgo(40)}, []string{}},
+		{"recent buyer", segmentSignals{Orders: 1, TotalSpend: 50, LastOrder: daysAgo(3)}, []string{segmentReturningBuyer}},
+		{"high spender", segmentSignals{Orders: 4, TotalSpend: 1200, LastOrder: daysAgo(3)}, []string{segmentReturningBuyer, segmentHighSpender}},
+		{"lapsed buyer", segmentSignals{Orders: 2, TotalSpend: 80, LastOrder: daysAgo(45)}, []string{segmentReturningBuyer, segmentLapsed}},
+		{"lapsed buyer with older events", segmentSignals{Orders: 2, TotalSpend: 80, LastOrder: daysAgo(45), FirstSeen: daysAgo(90)}, []string{segmentReturningBuyer, segmentLapsed}},
+		{"not lapsed yet", segmentSignals{Orders: 2, TotalSpend: 80, LastOrder: daysAgo(29)}, []string{segmentReturningBuyer}},
+		{"favorites heavy", segmentSignals{AccountCreated: daysAgo(20), Favorites: 5}, []string{segmentFavoritesHeavy}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := computeSegments(tt.signals, now); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("segments = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInAnySegment(t *testing.T) {
+	tests := []struct {
+		have, wanted []string
+		want         bool
+	}{
+		{nil, nil, false},
+		{[]string{segmentNewUser}, nil, false},
+		{[]string{segmentNewUser}, []string{segmentLapsed, segmentNewUser}, true},
+		{[]string{segmentReturningBuyer}, []string{segmentLapsed}, false},
+	}
+	for _, tt := range tests {
+		if got := inAnySegment(tt.have, tt.wanted); got != tt.want {
+			t.Errorf("inAnySegment(%v, %v) = %v, want %v", tt.have, tt.wanted, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ==================== UI CHANGE HUB ====================
+
+const (
+	uiChangeHistory   = 100
+	uiStreamBuffer    = 16
+	uiStreamHeartbeat = 15 * time.Second
+)
+
+// uiChange is one server-side change that makes clients' layouts stale.
+// Screen is empty when the change can affect every screen.
+type uiChange struct {
+	ID     uint64
+	Type   string // "mode" or "layout"
+	Screen string
+	Data   map[string]interface{}
+}
+
+func (c uiChange) affects(screen string) bool {
+	return c.Screen == "" || normalizeScreen(c.Screen) == normalizeScreen(screen)
+}
+
+// uiChangeHub numbers changes, keeps the latest few so reconnecting
+// streams can catch up, and fans them out to open streams
+type uiChangeHub struct {
+	mu          sync.Mutex
+	seq         uint64
+	history     []uiChange
+	subscribers map[chan uiChange]bool
+}
+
+var uiChanges = &uiChangeHub{subscribers: make(map[chan uiChange]bool)}
+
+func (h *uiChangeHub) publish(change uiChange) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	change.ID = h.seq
+	h.history = append(h.history, change)
+	if len(h.history) > uiChangeHistory {
+		h.history = h.history[len(h.history)-uiChangeHistory:]
+	}
+
+	for ch := range h.subscribers {
+		select {
+		case ch <- change:
+		default:
+			// Too far behind; dropping the stream makes the client
+			// reconnect and resume from its Last-Event-ID
+			delete(h.subscribers, ch)
+			close(ch)
+		}
+	}
+	log.Printf("📣 UI change #%d: %s %v (%d streams)", change.ID, change.Type, change.Data, len(h.subscribers))
+}
+
+// subscribe opens a stream. With a lastID it also returns the changes
+// published since, or resync=true when they're no longer in history.
+func (h *uiChangeHub) subscribe(lastID uint64, resuming bool) (ch chan uiChange, missed []uiChange, seq uint64, resync bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch = make(chan uiChange, uiStreamBuffer)
+	h.subscribers[ch] = true
+
+	if resuming && lastID != h.seq {
+		switch {
+		case lastID > h.seq:
+			resync = true // ids from before a restart
+		case len(h.history) == 0 || h.history[0].ID > lastID+1:
+			resync = true
+		default:
+			for _, change := range h.history {
+				if change.ID > lastID {
+					missed = append(missed, change)
+				}
+			}
+		}
+	}
+	return ch, missed, h.seq, resync
+}
+
+func (h *uiChangeHub) unsubscribe(ch chan uiChange) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subscribers[ch] {
+		delete(h.subscribers, ch)
+		close(ch)
+	}
+}
+
+// closeAll ends every open stream so the server can shut down
+func (h *uiChangeHub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers {
+		delete(h.subscribers, ch)
+		close(ch)
+	}
+}
+
+// watchModeChanges publishes a "mode" change at every schedule boundary
+func watchModeChanges(ctx context.Context) {
+	for {
+		previous := getCurrentMode()
+		_, end := currentModeWindow(time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(end)):
+		}
+
+		mode := getCurrentMode()
+		if mode == previous {
+			continue
+		}
+		renderCache.invalidate("mode_change", nil)
+		// Timers from the window that just closed stop at zero
+		publishCountdowns(previous, time.Now())
+		uiChanges.publish(uiChange{
+			Type: "mode",
+			Data: map[string]interface{}{
+				"mode":     mode,
+				"previous": previous,
+				"campaign": currentCampaign(time.Now()),
+			},
+		})
+	}
+}
+
+// currentCampaign describes the campaign (mode window) running at now
+func currentCampaign(now time.Time) map[string]interface{} {
+	_, end := currentModeWindow(now)
+	return map[string]interface{}{
+		"name":    modeForHour(now.Hour()),
+		"ends_at": end.Format(time.RFC3339),
+	}
+}
+
+// ==================== HANDLER ====================
+
+// handleUiStream serves GET /api/ui-stream?screen=<name> as Server-Sent
+// Events. Clients refetch /api/ui-config when a "mode" or "layout" event
+// arrives; reconnecting with Last-Event-ID replays what they missed, or
+// sends "resync" when that's no longer possible.
+func handleUiStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	screen := r.URL.Query().Get("screen")
+	if screen == "" {
+		screen = "/"
+	}
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	lastID, err := strconv.ParseUint(lastEventID, 10, 64)
+	resuming := lastEventID != ""
+	if resuming && err != nil {
+		http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+		return
+	}
+
+	ch, missed, seq, resync := uiChanges.subscribe(lastID, resuming)
+	defer uiChanges.unsubscribe(ch)
+	log.Printf("📡 UI stream opened - screen='%s', resume=%q", screen, lastEventID)
+	defer log.Printf("📴 UI stream closed - screen='%s'", screen)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: 3000\n\n")
+	state := map[string]interface{}{
+		"screen":   screen,
+		"mode":     getCurrentMode(),
+		"campaign": currentCampaign(time.Now()),
+	}
+	switch {
+	case !resuming:
+		writeStreamEvent(w, seq, "ready", state)
+	case resync:
+		writeStreamEvent(w, seq, "resync", state)
+	}
+	for _, change := range missed {
+		if change.affects(screen) {
+			writeStreamEvent(w, change.ID, change.Type, streamPayload(change, screen))
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(uiStreamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case change, open := <-ch:
+			if !open {
+				return
+			}
+			if change.affects(screen) {
+				writeStreamEvent(w, change.ID, change.Type, streamPayload(change, screen))
+				flusher.Flush()
+			}
+		case <-heartbeat.C:
+			fmt.Fprintf(w, ": heartbeat %s\n\n", time.Now().Format(time.RFC3339))
+			flusher.Flush()
+		}
+	}
+}
+
+func streamPayload(change uiChange, screen string) map[string]interface{} {
+	payload := map[string]interface{}{"screen": screen}
+	for key, value := range change.Data {
+		payload[key] = value
+	}
+	return payload
+}
+
+func writeStreamEvent(w http.ResponseWriter, id uint64, event string, data map[string]interface{}) {
+	encoded, _ := json.Marshal(data)
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, encoded)
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ==================== TEMPLATE CONTEXT ====================
+
+// Layout strings may embed {{expressions}} that are filled in per request:
+//
+//	"Good Morning, {{user.first_name | default \"there\"}}!"
+//	"{{cart.count | plural \"item\"}}"
+//	"Proceed to Checkout - {{cart.total | currency}}"
+//	"Ends in {{campaign.ends_in}}"
+//
+// An expression is a variable path optionally piped through functions.
+// Unknown variables and functions render as "" and are reported.
+
+// templateVars builds the values expressions can reference
+func templateVars(ctx RenderContext) map[string]interface{} {
+	vars := layoutTemplateVars(ctx)
+	for key, value := range requestTemplateVars(ctx) {
+		vars[key] = value
+	}
+	return vars
+}
+
+// layoutTemplateVars are the values shared by everyone seeing the same
+// cached layout
+func layoutTemplateVars(ctx RenderContext) map[string]interface{} {
+	_, campaignEnd := currentModeWindow(ctx.Now)
+
+	vars := map[string]interface{}{
+		"mode":             ctx.Mode,
+		"locale":           ctx.Locale,
+		"screen":           ctx.Screen,
+		"campaign.name":    ctx.Mode,
+		"campaign.ends_at": campaignEnd,
+	}
+	if ctx.ProductID != "" {
+		vars["product.id"] = ctx.ProductID
+		vars["product.stock"] = inventory.level(ctx.ProductID)
+	}
+	return vars
+}
+
+// requestTemplateVars are the values that change per shopper or per request
+func requestTemplateVars(ctx RenderContext) map[string]interface{} {
+	firstName := ""
+	if user, ok := users.get(ctx.UserID); ok {
+		firstName = user.FirstName
+	}
+
+	cartTotal := 0.0
+	if ctx.UserID != "" {
+		for _, item := range userData.cart(ctx.UserID) {
+			cartTotal += getProductByID(item.ProductID).Price * float64(item.Quantity)
+		}
+	}
+
+	_, campaignEnd := currentModeWindow(ctx.Now)
+
+	return map[string]interface{}{
+		"user.id":          ctx.UserID,
+		"user.first_name":  firstName,
+		"user.logged_in":   ctx.LoggedIn,
+		"cart.count":       ctx.CartCount,
+		"cart.total":       math.Round(cartTotal*100) / 100,
+		"now":              ctx.Now,
+		"campaign.ends_in": formatRemaining(campaignEnd.Sub(ctx.Now)),
+	}
+}
+
+// formatRemaining renders a duration as "1h 23m" or "12m"
+func formatRemaining(d time.Duration) string {
+	if d < time.Minute {
+		return "less than a minute"
+	}
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	if hours == 0 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %dm", hours, minutes)
+}
+
+// ==================== TEMPLATE FUNCTIONS ====================
+
+// templateFuncs transform a value with string arguments from the template
+var templateFuncs = map[string]func(value interface{}, args []string) (interface{}, error){
+	"currency": templateCurrency,
+	"plural":   templatePlural,
+	"date":     templateDate,
+	"default":  templateDefault,
+	"upper": func(value interface{}, args []string) (interface{}, error) {
+		return strings.ToUpper(formatTemplateValue(value)), nil
+	},
+}
+
+// currency formats a number as dollars: {{cart.total | currency}} -> "$589.97"
+func templateCurrency(value interface{}, args []string) (interface{}, error) {
+	amount, err := templateNumber(value)
+	if err != nil {
+		return nil, fmt.Errorf("currency: %w", err)
+	}
+	symbol := "$"
+	if len(args) > 0 {
+		symbol = args[0]
+	}
+	return fmt.Sprintf("%s%.2f", symbol, amount), nil
+}
+
+// plural prefixes a count to the right noun form:
+// {{cart.count | plural "item"}} -> "3 items", {{n | plural "box" "boxes"}}
+func templatePlural(value interface{}, args []string) (interface{}, error) {
+	count, err := templateNumber(value)
+	if err != nil {
+		return nil, fmt.Errorf("plural: %w", err)
+	}
+	if len(args) == 0 || len(args) > 2 {
+		return nil, fmt.Errorf("plural: expected a singular and optional plural form")
+	}
+	noun := args[0]
+	if count != 1 {
+		noun += "s"
+		if len(args) == 2 {
+			noun = args[1]
+		}
+	}
+	return fmt.Sprintf("%s %s", strconv.FormatFloat(count, 'f', -1, 64), noun), nil
+}
+
+// date formats a time with a Go layout, "Jan 2" by default:
+// {{campaign.ends_at | date "3:04 PM"}}
+func templateDate(value interface{}, args []string) (interface{}, error) {
+	t, ok := value.(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("date: %v is not a time", value)
+	}
+	layout := "Jan 2"
+	if len(args) > 0 {
+		layout = args[0]
+	}
+	return t.Format(layout), nil
+}
+
+// default substitutes a fallback for empty values: {{user.first_name | default "there"}}
+func templateDefault(value interface{}, args []string) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("default: expected one fallback")
+	}
+	if formatTemplateValue(value) == "" {
+		return args[0], nil
+	}
+	return value, nil
+}
+
+// t looks up a catalog message in the request's locale; piping a number in
+// picks the plural form: {{t "cart.title"}}, {{cart.count | t "cart.item_count"}}.
+// Messages may themselves contain expressions, nested up to maxMessageDepth
+// so a message that refers to itself fails instead of looping.
+func templateTranslate(value interface{}, args []string, vars map[string]interface{}, depth int) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("t: expected a message key")
+	}
+	if depth >= maxMessageDepth {
+		return nil, fmt.Errorf("message %q nests more than %d deep", args[0], maxMessageDepth)
+	}
+	var count *float64
+	if value != nil {
+		n, err := templateNumber(value)
+		if err != nil {
+			return nil, fmt.Errorf("t: %w", err)
+		}
+		count = &n
+	}
+
+	locale, _ := vars["locale"].(string)
+	text, ok := translate(locale, args[0], count)
+	if !ok {
+		return nil, fmt.Errorf("unknown message key %q", args[0])
+	}
+	return expandTemplate(text, vars, depth+1)
+}
+
+func templateNumber(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	}
+	return 0, fmt.Errorf("%v is not a number", value)
+}
+
+func formatTemplateValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprint(value)
+}
+
+// ==================== RENDERING ====================
+
+// maxMessageDepth bounds how deeply catalog messages may nest {{t}} lookups
+const maxMessageDepth = 8
+
+// renderTemplate expands every {{expression}} in s
+func renderTemplate(s string, vars map[string]interface{}) (string, error) {
+	return expandTemplate(s, vars, 0)
+}
+
+// expandTemplate renders s inside depth levels of message lookups
+func expandTemplate(s string, vars map[string]interface{}, depth int) (string, error) {
+	if !strings.Contains(s, "{{") {
+		return s, nil
+	}
+
+	var out strings.Builder
+	var firstErr error
+	for {
+		open := strings.Index(s, "{{")
+		if open < 0 {
+			break
+		}
+		end := strings.Index(s[open:], "}}")
+		if end < 0 {
+			out.WriteString(s)
+			return out.String(), fmt.Errorf("unclosed {{ in %q", s)
+		}
+		out.WriteString(s[:open])
+
+		value, err := evaluateExpression(s[open+2:open+end], vars, depth)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if err == nil {
+			out.WriteString(formatTemplateValue(value))
+		}
+		s = s[open+end+2:]
+	}
+	out.WriteString(s)
+	return out.String(), firstErr
+}
+
+// evaluateExpression resolves "path | func arg | func" against vars. An
+// expression may also start with a message lookup: {{t "cart.title"}}.
+func evaluateExpression(expr string, vars map[string]interface{}, depth int) (interface{}, error) {
+	stages := strings.Split(expr, "|")
+	name := strings.TrimSpace(stages[0])
+	value, ok := vars[name]
+	if words, err := splitTemplateArgs(name); err == nil && len(words) > 0 && words[0] == "t" {
+		if value, err = templateTranslate(nil, words[1:], vars, depth); err != nil {
+			return nil, err
+		}
+	} else if !ok {
+		return nil, fmt.Errorf("unknown template variable %q", name)
+	}
+
+	for _, stage := range stages[1:] {
+		words, err := splitTemplateArgs(stage)
+		if err != nil {
+			return nil, err
+		}
+		if len(words) == 0 {
+			return nil, fmt.Errorf("empty pipeline stage in {{%s}}", strings.TrimSpace(expr))
+		}
+		if words[0] == "t" {
+			if value, err = templateTranslate(value, words[1:], vars, depth); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		fn, ok := templateFuncs[words[0]]
+		if !ok {
+			return nil, fmt.Errorf("unknown template function %q", words[0])
+		}
+		if value, err = fn(value, words[1:]); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// splitTemplateArgs splits a pipeline stage on spaces, honouring "quoted strings"
+func splitTemplateArgs(stage string) ([]string, error) {
+	var words []string
+	rest := strings.TrimSpace(stage)
+	for rest != "" {
+		if rest[0] == '"' {
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return nil, fmt.Errorf("bad string argument in %q", stage)
+			}
+			word, _ := strconv.Unquote(quoted)
+			words = append(words, word)
+			rest = strings.TrimSpace(rest[len(quoted):])
+			continue
+		}
+		end := strings.IndexFunc(rest, unicode.IsSpace)
+		if end < 0 {
+			end = len(rest)
+		}
+		words = append(words, rest[:end])
+		rest = strings.TrimSpace(rest[end:])
+	}
+	return words, nil
+}
+
+func renderTemplateValue(value interface{}, path string, vars map[string]interface{}, problems *[]string) interface{} {
+	switch v := value.(type) {
+	case string:
+		rendered, err := renderTemplate(v, vars)
+		if err != nil {
+			*problems = append(*problems, fmt.Sprintf("%s: %v", path, err))
+		}
+		return rendered
+	case map[string]interface{}:
+		for key, child := range v {
+			childPath := path + "." + key
+			if id, ok := v["id"].(string); ok && key != "id" {
+				childPath = path + "[" + id + "]." + key
+			}
+			v[key] = renderTemplateValue(child, childPath, vars, problems)
+		}
+		return v
+	case []interface{}:
+		for i, child := range v {
+			v[i] = renderTemplateValue(child, path, vars, problems)
+		}
+		return v
+	case []map[string]interface{}:
+		for _, child := range v {
+			renderTemplateValue(child, path, vars, problems)
+		}
+		return v
+	}
+	return value
+}
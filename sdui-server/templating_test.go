@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	quietLogs(t)
+	vars := map[string]interface{}{
+		"locale":           "en",
+		"user.first_name":  "Ada",
+		"user.last_name":   "",
+		"cart.count":       3,
+		"cart.total":       589.97,
+		"one":              1,
+		"campaign.ends_at": time.Date(2025, 6, 1, 14, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{"plain text", "plain text", false},
+		{"Hi {{user.first_name}}!", "Hi Ada!", false},
+		{"{{ user.first_name | upper }}", "ADA", false},
+		{`{{user.last_name | default "Friend"}}`, "Friend", false},
+		{`{{user.first_name | default "Friend"}}`, "Ada", false},
+		{"{{cart.total | currency}}", "$589.97", false},
+		{`{{cart.total | currency "€"}}`, "€589.97", false},
+		{`{{cart.count | plural "item"}}`, "3 items", false},
+		{`{{one | plural "box" "boxes"}}`, "1 box", false},
+		{`{{cart.count | plural "box" "boxes"}}`, "3 boxes", false},
+		{`Ends {{campaign.ends_at | date "3:04 PM"}}`, "Ends 2:00 PM", false},
+		{`{{t "cart.title"}}`, "Shopping Cart", false},
+		{`{{cart.count | t "cart.item_count"}}`, "3 items", false},
+		{`{{one | t "cart.item_count"}}`, "1 item", false},
+		{"{{a}} and {{user.first_name}}", " and Ada", true},
+		{"{{user.first_name | shout}}", "", true},
+		{"{{user.first_name | currency}}", "", true},
+		{`{{t "no.such.key"}}`, "", true},
+		{"{{user.first_name", "{{user.first_name", true},
+		{`{{user.first_name | default "unterminated}}`, "", true},
+	}
+	for _, tt := range tests {
+		got, err := renderTemplate(tt.template, vars)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, want error %v", tt.template, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestSplitTemplateArgs(t *testing.T) {
+	tests := []struct {
+		stage string
+		want  []string
+	}{
+		{"", nil},
+		{" upper ", []string{"upper"}},
+		{`default "there"`, []string{"default", "there"}},
+		{`plural "box" "boxes"`, []string{"plural", "box", "boxes"}},
+		{`date "3:04 PM"`, []string{"date", "3:04 PM"}},
+		{`default "say \"hi\""`, []string{"default", `say "hi"`}},
+	}
+	for _, tt := range tests {
+		got, err := splitTemplateArgs(tt.stage)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitTemplateArgs(%q) = %q, %v; want %q", tt.stage, got, err, tt.want)
+		}
+	}
+}
+
+func TestFormatRemaining(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{-time.Minute, "less than a minute"},
+		{30 * time.Second, "less than a minute"},
+		{12 * time.Minute, "12m"},
+		{83 * time.Minute, "1h 23m"},
+		{2 * time.Hour, "2h 0m"},
+	}
+	for _, tt := range tests {
+		if got := formatRemaining(tt.d); got != tt.want {
+			t.Errorf("formatRemaining(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestApplyLayoutTemplates(t *testing.T) {
+	quietLogs(t)
+	config := map[string]interface{}{
+		"components": []interface{}{
+			map[string]interface{}{"id": "header", "props": map[string]interface{}{
+				"title":    `{{t "cart.title"}} ({{mode}})`,
+				"subtitle": `Hello {{user.first_name | default "there"}}`,
+				"broken":   "{{nope}}",
+			}},
+		},
+	}
+	problems := applyLayoutTemplates(config, RenderContext{Screen: "/cart", Mode: "day", Locale: "en", Now: time.Now()})
+
+	props := config["components"].([]interface{})[0].(map[string]interface{})["props"].(map[string]interface{})
+	tests := []struct {
+		key  string
+		want interface{}
+	}{
+		// Layout values render into the entry; per-request ones wait for render
+		{"title", "Shopping Cart (day)"},
+		{"subtitle", dynamicText(`Hello {{user.first_name | default "there"}}`)},
+		{"broken", dynamicText("{{nope}}")},
+	}
+	for _, tt := range tests {
+		if props[tt.key] != tt.want {
+			t.Errorf("%s = %#v, want %#v", tt.key, props[tt.key], tt.want)
+		}
+	}
+	if len(problems) != 1 || !strings.Contains(problems[0], "[header].props.broken") {
+		t.Errorf("problems = %v, want one for the unknown variable", problems)
+	}
+}
+
+// The cached entry renders per-request templates on every render, in the
+// entry's locale, while layout text is shared
+func TestRenderEntryTemplates(t *testing.T) {
+	quietLogs(t)
+	userID := "guest_templating_test"
+	t.Cleanup(func() { userData.removeFromCart(userID, "prod_2") })
+
+	now := time.Now()
+	ctx := func(locale string) RenderContext {
+		count := 0
+		for _, item := range userData.cart(userID) {
+			count += item.Quantity
+		}
+		return RenderContext{Screen: "/cart", Mode: "day", UserID: userID, Locale: locale, CartCount: count, Now: now}
+	}
+	price := getProductByID("prod_2").Price
+
+	userData.addToCart(userID, "prod_2", 1)
+	english := buildRenderEntry("/cart", "day", ctx("en"))
+	french := buildRenderEntry("/cart", "day", ctx("fr"))
+
+	tests := []struct {
+		name     string
+		entry    *renderEntry
+		quantity int
+		title    string
+		subtitle string
+		checkout string
+	}{
+		{"one item", english, 1, "Shopping Cart", "1 item", fmt.Sprintf("Proceed to Checkout - $%.2f", price)},
+		{"cart changed, same entry", english, 3, "Shopping Cart", "3 items", fmt.Sprintf("Proceed to Checkout - $%.2f", 3*price)},
+		{"french entry", french, 3, "Panier", "3 articles", fmt.Sprintf("Passer commande - €%.2f", 3*price)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userData.removeFromCart(userID, "prod_2")
+			userData.addToCart(userID, "prod_2", tt.quantity)
+			locale := "en"
+			if tt.entry == french {
+				locale = "fr"
+			}
+			body, _ := tt.entry.render(ctx(locale))
+
+			var config map[string]interface{}
+			if err := json.Unmarshal(body, &config); err != nil {
+				t.Fatal(err)
+			}
+			if errors, ok := config["metadata"].(map[string]interface{})["template_errors"]; ok {
+				t.Errorf("template errors: %v", errors)
+			}
+			got := map[string]string{}
+			for _, c := range config["components"].([]interface{}) {
+				component := c.(map[string]interface{})
+				props, _ := component["props"].(map[string]interface{})
+				switch component["id"] {
+				case "cart-header":
+					got["title"], _ = props["title"].(string)
+					got["subtitle"], _ = props["subtitle"].(string)
+				case "checkout-button":
+					got["checkout"], _ = props["label"].(string)
+				}
+			}
+			want := map[string]string{"title": tt.title, "subtitle": tt.subtitle, "checkout": tt.checkout}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("rendered %v, want %v", got, want)
+			}
+		})
+	}
+}
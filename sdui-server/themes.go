@@ -0,0 +1,251 @@
+package main
+
+import (
+	"log"
+	"strings"
+)
+
+// ==================== THEME REGISTRY ====================
+
+// Theme is a named set of design tokens. Every screen gets the complete
+// theme for its mode, and component styles can reference its tokens:
+//
+//	"color":      "$primary"              (colors)
+//	"fontSize":   "$font_sizes.headline"
+//	"fontWeight": "$font_weights.bold"
+//	"padding":    "$spacing.md"
+//	"radius":     "$border_radius.lg"
+type Theme struct {
+	Name        string
+	Dark        bool
+	Colors      map[string]string
+	FontSizes   map[string]float64
+	FontWeights map[string]string
+	Spacing     map[string]float64
+	Radius      float64 // the default corner radius; sm/lg are derived from it
+}
+
+var defaultFontWeights = map[string]string{
+	"regular": "normal",
+	"medium":  "w500",
+	"bold":    "bold",
+}
+
+var standardSpacing = map[string]float64{"xs": 4.0, "sm": 8.0, "md": 16.0, "lg": 24.0, "xl": 32.0}
+
+func lightColors(primary, background, accent string) map[string]string {
+	return map[string]string{
+		"primary":        primary,
+		"background":     background,
+		"accent":         accent,
+		"surface":        "#FFFFFF",
+		"text":           "#212121",
+		"text_secondary": "#757575",
+		"success":        "#4CAF50",
+		"warning":        "#FFB800",
+	}
+}
+
+func darkColors(primary, background, accent, surface string) map[string]string {
+	return map[string]string{
+		"primary":        primary,
+		"background":     background,
+		"accent":         accent,
+		"surface":        surface,
+		"text":           "#FFFFFF",
+		"text_secondary": "#B0B0B0",
+		"success":        "#4CAF50",
+		"warning":        "#FFB800",
+	}
+}
+
+func fontScale(headline, title, body, caption float64) map[string]float64 {
+	return map[string]float64{"headline": headline, "title": title, "body": body, "caption": caption}
+}
+
+// themeRegistry holds one theme per UI mode, keyed by mode name
+var themeRegistry = map[string]Theme{
+	"late_night": {
+		Name:      "late_night",
+		Dark:      true,
+		Colors:    darkColors("#0A0A0A", "#000000", "#555555", "#1A1A1A"),
+		FontSizes: fontScale(24, 18, 14, 11),
+		Spacing:   standardSpacing,
+		Radius:    8,
+	},
+	"morning": {
+		Name:      "morning",
+		Colors:    lightColors("#FF9800", "#FFFBF5", "#FFC107"),
+		FontSizes: fontScale(34, 20, 16, 12),
+		Spacing:   standardSpacing,
+		Radius:    16,
+	},
+	"day": {
+		Name:      "day",
+		Colors:    lightColors("#2C3E50", "#FFFFFF", "#3498DB"),
+		FontSizes: fontScale(32, 20, 16, 12),
+		Spacing:   standardSpacing,
+		Radius:    12,
+	},
+	"flash_sale": {
+		Name:      "flash_sale",
+		Colors:    lightColors("#FF4757", "#FFFFFF", "#FF6B6B"),
+		FontSizes: fontScale(28, 18, 14, 11),
+		Spacing:   map[string]float64{"xs": 2.0, "sm": 4.0, "md": 8.0, "lg": 12.0, "xl": 16.0},
+		Radius:    8,
+	},
+	"afternoon": {
+		Name:      "afternoon",
+		Colors:    lightColors("#00BCD4", "#F0F8FF", "#00ACC1"),
+		FontSizes: fontScale(30, 19, 15, 12),
+		Spacing:   standardSpacing,
+		Radius:    12,
+	},
+	"evening": {
+		Name:      "evening",
+		Colors:    lightColors("#6C5CE7", "#FAF9F6", "#A29BFE"),
+		FontSizes: fontScale(36, 22, 17, 13),
+		Spacing:   map[string]float64{"xs": 4.0, "sm": 8.0, "md": 18.0, "lg": 28.0, "xl": 40.0},
+		Radius:    16,
+	},
+	"night": {
+		Name:      "night",
+		Dark:      true,
+		Colors:    darkColors("#1A1A1A", "#0A0A0A", "#FFD700", "#1A1A2E"),
+		FontSizes: fontScale(40, 28, 18, 14),
+		Spacing:   map[string]float64{"xs": 4.0, "sm": 8.0, "md": 20.0, "lg": 32.0, "xl": 48.0},
+		Radius:    20,
+	},
+}
+
+func (t Theme) radii() map[string]float64 {
+	return map[string]float64{"sm": t.Radius / 2, "md": t.Radius, "lg": t.Radius * 1.5}
+}
+
+// toMap renders the theme in the shape clients read, keeping the flat
+// primary/background/accent keys older app versions use
+func (t Theme) toMap() map[string]interface{} {
+	colors := map[string]interface{}{}
+	for name, value := range t.Colors {
+		colors[name] = value
+	}
+	fontSizes := map[string]float64{}
+	for name, value := range t.FontSizes {
+		fontSizes[name] = value
+	}
+	spacing := map[string]float64{}
+	for name, value := range t.Spacing {
+		spacing[name] = value
+	}
+	weights := t.FontWeights
+	if weights == nil {
+		weights = defaultFontWeights
+	}
+	fontWeights := map[string]interface{}{}
+	for name, value := range weights {
+		fontWeights[name] = value
+	}
+
+	return map[string]interface{}{
+		"name":             t.Name,
+		"is_dark_mode":     t.Dark,
+		"primary_color":    t.Colors["primary"],
+		"background_color": t.Colors["background"],
+		"accent_color":     t.Colors["accent"],
+		"colors":           colors,
+		"font_sizes":       fontSizes,
+		"font_weights":     fontWeights,
+		"spacing":          spacing,
+		"border_radius":    t.Radius,
+		"border_radii":     t.radii(),
+	}
+}
+
+// getThemeForMode returns the complete theme for a mode, day's as fallback
+func getThemeForMode(mode string) map[string]interface{} {
+	theme, ok := themeRegistry[mode]
+	if !ok {
+		theme = themeRegistry["day"]
+	}
+	return theme.toMap()
+}
+
+// ==================== TOKEN RESOLUTION ====================
+
+// resolveThemeTokens replaces "$token" strings in component styles with
+// values from the config's theme. Unknown tokens are left in place and logged.
+func resolveThemeTokens(config map[string]interface{}) {
+	theme, _ := config["theme"].(map[string]interface{})
+	components, _ := config["components"].([]interface{})
+	if theme != nil {
+		resolveComponentTokens(components, theme)
+	}
+}
+
+func resolveComponentTokens(components []interface{}, theme map[string]interface{}) {
+	for _, c := range components {
+		component, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if style, ok := component["style"].(map[string]interface{}); ok {
+			resolveTokenValue(style, theme)
+		}
+		if children, ok := component["children"].([]interface{}); ok {
+			resolveComponentTokens(children, theme)
+		}
+	}
+}
+
+func resolveTokenValue(value interface{}, theme map[string]interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if !strings.HasPrefix(v, "$") {
+			return v
+		}
+		if resolved, ok := lookupThemeToken(theme, v[1:]); ok {
+			return resolved
+		}
+		log.Printf("⚠️  Unknown theme token %q", v)
+		return v
+	case map[string]interface{}:
+		for key, child := range v {
+			v[key] = resolveTokenValue(child, theme)
+		}
+		return v
+	case []interface{}:
+		for i, child := range v {
+			v[i] = resolveTokenValue(child, theme)
+		}
+		return v
+	case []string:
+		resolved := make([]interface{}, len(v))
+		for i, child := range v {
+			resolved[i] = resolveTokenValue(child, theme)
+		}
+		return resolved
+	}
+	return value
+}
+
+// lookupThemeToken resolves "primary" against colors, and "group.name"
+// against the theme's token groups
+func lookupThemeToken(theme map[string]interface{}, token string) (interface{}, bool) {
+	group, name, grouped := strings.Cut(token, ".")
+	if !grouped {
+		group, name = "colors", token
+	}
+	if group == "border_radius" {
+		group = "border_radii"
+	}
+
+	switch tokens := theme[group].(type) {
+	case map[string]interface{}:
+		value, ok := tokens[name]
+		return value, ok
+	case map[string]float64:
+		value, ok := tokens[name]
+		return value, ok
+	}
+	return nil, false
+}
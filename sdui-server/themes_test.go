@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func findComponent(components []interface{}, id string) map[string]interface{} {
+	for _, c := range components {
+		component, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if component["id"] == id {
+			return component
+		}
+		if children, ok := component["children"].([]interface{}); ok {
+			if found := findComponent(children, id); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// Swapping hex values for tokens must not change what a screen looks like
+func TestResolvedTokenColors(t *testing.T) {
+	tests := []struct {
+		screen, mode, component, key string
+		want                         string
+	}{
+		{"/product", "day", "price", "color", "#2C3E50"},
+		{"/", "late_night", "night-message", "color", "#FFFFFF"},
+		{"/", "late_night", "minimal-products", "priceColor", "#FFFFFF"},
+	}
+	for _, tt := range tests {
+		config := buildScreenConfig(tt.screen, tt.mode, RenderContext{Screen: tt.screen, Mode: tt.mode, ProductID: "prod_1"})
+		resolveThemeTokens(config)
+		component := findComponent(config["components"].([]interface{}), tt.component)
+		if component == nil {
+			t.Fatalf("%s %s: no %s component", tt.screen, tt.mode, tt.component)
+		}
+		style, _ := component["style"].(map[string]interface{})
+		if got := style[tt.key]; got != tt.want {
+			t.Errorf("%s %s %s.%s = %v, want %s", tt.screen, tt.mode, tt.component, tt.key, got, tt.want)
+		}
+	}
+}
@@ -1,9 +1,9 @@
 package main
 
 import (
-	"encoding/json"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -11,7 +11,11 @@ import (
 
 // getCurrentMode determines which UI mode to show based on time and user behavior
 func getCurrentMode() string {
-	hour := time.Now().Hour()
+	return modeForHour(time.Now().Hour())
+}
+
+// modeForHour is the mode schedule: which mode runs during a given hour
+func modeForHour(hour int) string {
 	// Time-based modes
 	switch {
 	case hour >= 0 && hour < 6:
@@ -33,45 +37,89 @@ func getCurrentMode() string {
 	}
 }
 
+// modeBoundaries are the hours at which getCurrentMode switches modes
+var modeBoundaries = []int{0, 6, 9, 12, 14, 17, 20, 24}
+
+// currentModeWindow returns when the mode active at now started and ends
+func currentModeWindow(now time.Time) (start, end time.Time) {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for i := 1; i < len(modeBoundaries); i++ {
+		if now.Hour() < modeBoundaries[i] {
+			start = midnight.Add(time.Duration(modeBoundaries[i-1]) * time.Hour)
+			end = midnight.Add(time.Duration(modeBoundaries[i]) * time.Hour)
+			break
+		}
+	}
+	return start, end
+}
+
 // ==================== UI CONFIG HANDLER ====================
 
 func handleUiConfig(w http.ResponseWriter, r *http.Request) {
-	screen := r.URL.Query().Get("screen")
-	if screen == "" {
-		screen = "/"
-	}
+	// Normalized once so the cache key, the layout built and the tracking
+	// tokens all agree however the client spelled the screen
+	screen := screenRoute(r.URL.Query().Get("screen"))
 
-	userId := r.Header.Get("X-User-ID")
+	userId := currentUserID(r)
 	mode := getCurrentMode()
 
 	log.Printf("🎨 UI Config Request - screen='%s', mode='%s', user='%s'", screen, mode, userId)
 
-	var config interface{}
+	renderCtx := newRenderContext(r, screen, mode)
+	assignments := experimentAssignments(screen, mode, userId)
+	logExposure(assignments, screen, mode, userId)
 
-	switch screen {
-	case "/", "home":
-		config = getHomeScreenConfig(mode, userId)
+	key := newRenderKey(screen, mode, renderCtx, assignments)
+	entry, hit := renderCache.get(key, renderCtx.Now)
+	if !hit {
+		entry = buildRenderEntry(screen, mode, renderCtx)
+		renderCache.put(key, entry)
+	}
+	body, revision := entry.render(renderCtx)
+
+	w.Header().Set("X-UI-Mode", mode)
+	w.Header().Set("Content-Language", renderCtx.Locale)
+	w.Header().Set("Vary", "Accept, Accept-Language, Accept-Encoding, Authorization, X-Accessibility, X-Base-Revision, X-Platform, X-App-Version")
+	w.Header().Set("X-Generated-At", time.Now().Format(time.RFC3339))
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if hit {
+		w.Header().Set("X-Render-Cache", "hit")
+	} else {
+		w.Header().Set("X-Render-Cache", "miss")
+	}
+
+	writeConfig(w, r, servedConfigs.forScreen(screen, mode), revision, body, &entry.compressed)
+}
+
+// screenRoute maps a requested screen onto its route: "cart" and "/cart"
+// are the same screen, and "", "home" and "/" are all home
+func screenRoute(screen string) string {
+	screen = strings.Trim(strings.TrimSpace(screen), "/")
+	if screen == "" || screen == "home" {
+		return "/"
+	}
+	return "/" + screen
+}
+
+// buildScreenConfig builds a screen's base layout before any per-request
+// passes run
+func buildScreenConfig(screen, mode string, ctx RenderContext) map[string]interface{} {
+	switch screenRoute(screen) {
+	case "/":
+		return getHomeScreenConfig(mode, ctx.UserID)
 	case "/product":
-		productID := r.URL.Query().Get("id")
-		config = getProductScreenConfig(mode, productID)
+		return getProductScreenConfig(mode, ctx.ProductID)
 	case "/cart":
-		config = getCartScreenConfig(mode)
+		return getCartScreenConfig(mode)
 	case "/profile":
-		config = getProfileScreenConfig(mode)
+		return getProfileScreenConfig(mode)
 	case "/search":
-		config = getSearchScreenConfig(mode)
+		return getSearchScreenConfig(mode)
 	case "/favorites":
-		config = getFavoritesScreenConfig(mode)
+		return getFavoritesScreenConfig(mode)
 	default:
-		config = getHomeScreenConfig(mode, userId)
+		return getHomeScreenConfig(mode, ctx.UserID)
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("X-UI-Mode", mode)
-	w.Header().Set("X-Generated-At", time.Now().Format(time.RFC3339))
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-
-	json.NewEncoder(w).Encode(config)
 }
 
 // ==================== NAVIGATION STATE MANAGEMENT ====================
@@ -79,11 +127,11 @@ func handleUiConfig(w http.ResponseWriter, r *http.Request) {
 func getNavigationConfig(screen string, mode string) map[string]interface{} {
 	// Define all navigation items with their base configuration
 	allNavItems := []map[string]interface{}{
-		{"id": "nav-home", "label": "Home", "icon": "home", "route": "/"},
-		{"id": "nav-search", "label": "Search", "icon": "search", "route": "/search"},
-		{"id": "nav-cart", "label": "Cart", "icon": "cart", "route": "/cart"},
-		{"id": "nav-favorites", "label": "Favorites", "icon": "favorite", "route": "/favorites"},
-		{"id": "nav-profile", "label": "Profile", "icon": "person", "route": "/profile"},
+		{"id": "nav-home", "label": msg("nav.home"), "icon": "home", "route": "/"},
+		{"id": "nav-search", "label": msg("nav.search"), "icon": "search", "route": "/search"},
+		{"id": "nav-cart", "label": msg("nav.cart"), "icon": "cart", "route": "/cart"},
+		{"id": "nav-favorites", "label": msg("nav.favorites"), "icon": "favorite", "route": "/favorites"},
+		{"id": "nav-profile", "label": msg("nav.profile"), "icon": "person", "route": "/profile"},
 	}
 
 	// Determine which nav items to show based on mode
@@ -140,21 +188,21 @@ func getNavigationConfig(screen string, mode string) map[string]interface{} {
 
 		// Special cases for different modes
 		if mode == "flash_sale" && route == "/" {
-			navMap["label"] = "Deals"
+			navMap["label"] = msg("nav.deals")
 		}
 		if mode == "evening" {
 			if route == "/" {
-				navMap["label"] = "Discover"
+				navMap["label"] = msg("nav.discover")
 			}
 			if route == "/favorites" {
-				navMap["label"] = "Saved"
+				navMap["label"] = msg("nav.saved")
 			}
 			if route == "/profile" {
-				navMap["label"] = "You"
+				navMap["label"] = msg("nav.you")
 			}
 		}
 		if mode == "night" && route == "/search" {
-			navMap["label"] = "Explore"
+			navMap["label"] = msg("nav.explore")
 		}
 	}
 
@@ -171,15 +219,15 @@ func getScreenTitle(screen string) string {
 	case "/", "home":
 		return ""
 	case "/search":
-		return "Search"
+		return msg("screen.search")
 	case "/cart":
-		return "Cart"
+		return msg("screen.cart")
 	case "/favorites":
-		return "Favorites"
+		return msg("screen.favorites")
 	case "/profile":
-		return "Profile"
+		return msg("screen.profile")
 	case "/product":
-		return "Product Details"
+		return msg("screen.product")
 	default:
 		return ""
 	}
@@ -208,758 +256,538 @@ func getHomeScreenConfig(mode string, userId string) map[string]interface{} {
 
 // 🌙 LATE NIGHT MODE (12AM - 6AM): Absolute Minimal
 func getLateNightModeConfig() map[string]interface{} {
-	return map[string]interface{}{
-		"screen_id":   "home",
-		"layout_type": "scroll",
-		"theme": map[string]interface{}{
-			"is_dark_mode":     true,
-			"primary_color":    "#0A0A0A",
-			"background_color": "#000000",
-			"accent_color":     "#555555",
-			"font_sizes": map[string]float64{
-				"headline": 24.0,
-				"title":    18.0,
-				"body":     14.0,
-				"caption":  11.0,
-			},
-			"border_radius": 8.0,
-			"spacing": map[string]float64{
-				"xs": 4.0,
-				"sm": 8.0,
-				"md": 16.0,
-				"lg": 24.0,
-				"xl": 32.0,
+	return homeLayout("late_night", []interface{}{
+		fragment("section-header", map[string]interface{}{
+			"id": "night-message",
+			"props": map[string]interface{}{
+				"title":     msg("late_night.header.title"),
+				"subtitle":  msg("late_night.header.subtitle"),
+				"alignment": "center",
+				"showIcon":  true,
+				"icon":      "star",
 			},
-		},
-		"components": []interface{}{
-			map[string]interface{}{
-				"id":   "night-message",
-				"type": "header",
-				"props": map[string]interface{}{
-					"title":     "Still browsing?",
-					"subtitle":  "Here's what you might like",
-					"alignment": "center",
-					"showIcon":  true,
-					"icon":      "star",
-				},
-				"style": map[string]interface{}{
-					"padding":         24.0,
-					"fontSize":        24.0,
-					"color":           "#FFFFFF",
-					"backgroundColor": "#1A1A1A",
-					"borderRadius":    12.0,
-				},
+			"style": map[string]interface{}{
+				"padding":         24.0,
+				"color":           "$text",
+				"backgroundColor": "$surface",
+				"borderRadius":    12.0,
 			},
-			map[string]interface{}{
-				"id":    "spacer",
-				"type":  "spacer",
-				"props": map[string]interface{}{"height": 20.0},
+		}),
+		map[string]interface{}{
+			"id":    "spacer",
+			"type":  "spacer",
+			"props": map[string]interface{}{"height": 20.0},
+		},
+		fragment("standard-product-grid", map[string]interface{}{
+			"id": "minimal-products",
+			"props": map[string]interface{}{
+				"columns":     1,
+				"spacing":     20.0,
+				"aspectRatio": 1.5,
+				"products":    getMidnightProducts(),
 			},
-			map[string]interface{}{
-				"id":   "minimal-products",
-				"type": "product_grid",
-				"props": map[string]interface{}{
-					"columns":     1,
-					"spacing":     20.0,
-					"aspectRatio": 1.5,
-					"products":    getMidnightProducts(),
-				},
-				"style": map[string]interface{}{
-					"imageHeight":  200.0,
-					"borderRadius": 12.0,
-					"showDiscount": false,
-					"showRating":   false,
-					"titleSize":    18.0,
-					"priceSize":    20.0,
-					"priceColor":   "#FFFFFF",
-					"elevation":    1.0,
-				},
+			"style": map[string]interface{}{
+				"showDiscount":   false,
+				"showRating":     false,
+				"showFavorite":   nil,
+				"contentPadding": nil,
+				"titleSize":      18.0,
+				"priceSize":      20.0,
+				"priceColor":     "$text",
+				"elevation":      1.0,
 			},
-		},
-		"navigation": getNavigationConfig("/", "late_night"),
-		"metadata":   getMetadata("late_night"),
-	}
+		}),
+	})
 }
 
 // ☀️ MORNING MODE (6AM - 9AM): Fresh Start
 func getMorningModeConfig() map[string]interface{} {
-	return map[string]interface{}{
-		"screen_id":   "home",
-		"layout_type": "scroll",
-		"theme": map[string]interface{}{
-			"is_dark_mode":     false,
-			"primary_color":    "#FF9800",
-			"background_color": "#FFFBF5",
-			"accent_color":     "#FFC107",
-			"font_sizes": map[string]float64{
-				"headline": 34.0,
-				"title":    20.0,
-				"body":     16.0,
-				"caption":  12.0,
-			},
-			"border_radius": 16.0,
-			"spacing": map[string]float64{
-				"xs": 4.0,
-				"sm": 8.0,
-				"md": 16.0,
-				"lg": 24.0,
-				"xl": 32.0,
+	return homeLayout("morning", []interface{}{
+		fragment("section-header", map[string]interface{}{
+			"id": "morning-greeting",
+			"props": map[string]interface{}{
+				"title":    msg("morning.greeting.title"),
+				"subtitle": msg("morning.greeting.subtitle"),
+			},
+			"style": map[string]interface{}{
+				"padding": 20.0,
+			},
+		}),
+		map[string]interface{}{
+			"id":   "search",
+			"type": "search_bar",
+			"props": map[string]interface{}{
+				"placeholder": msg("morning.search.placeholder"),
+				"showFilter":  true,
+			},
+			"style": map[string]interface{}{
+				"margin":          16.0,
+				"backgroundColor": "#FFFFFF",
+				"borderRadius":    12.0,
 			},
 		},
-		"components": []interface{}{
-			map[string]interface{}{
-				"id":   "morning-greeting",
-				"type": "header",
-				"props": map[string]interface{}{
-					"title":     "Good Morning, People! ☀️",
-					"subtitle":  "Start your day with great finds",
-					"alignment": "left",
-				},
-				"style": map[string]interface{}{
-					"padding":  20.0,
-					"fontSize": 34.0,
-					"color":    "#FF9800",
-				},
+		map[string]interface{}{
+			"id":   "morning-stories",
+			"type": "story_circle",
+			"flag": "morning_stories",
+			"props": map[string]interface{}{
+				"stories": getMorningStories(),
 			},
-			map[string]interface{}{
-				"id":   "search",
-				"type": "search_bar",
-				"props": map[string]interface{}{
-					"placeholder": "What are you looking for?",
-					"showFilter":  true,
-				},
-				"style": map[string]interface{}{
-					"margin":          16.0,
-					"backgroundColor": "#FFFFFF",
-					"borderRadius":    12.0,
-				},
+			"style": map[string]interface{}{
+				"padding": 16.0,
 			},
-			map[string]interface{}{
-				"id":   "morning-stories",
-				"type": "story_circle",
-				"props": map[string]interface{}{
-					"stories": getMorningStories(),
-				},
-				"style": map[string]interface{}{
-					"padding": 16.0,
-				},
+		},
+		fragment("promo-banner", map[string]interface{}{
+			"id": "morning-deals",
+			"props": map[string]interface{}{
+				"title":      msg("morning.deals.title"),
+				"subtitle":   msg("morning.deals.subtitle"),
+				"buttonText": msg("common.shop_now"),
+				"height":     180.0,
 			},
-			map[string]interface{}{
-				"id":   "morning-deals",
-				"type": "banner",
-				"props": map[string]interface{}{
-					"title":      "Early Bird Specials",
-					"subtitle":   "Extra 15% off before 9 AM",
-					"buttonText": "Shop Now",
-					"height":     180.0,
-				},
-				"style": map[string]interface{}{
-					"backgroundColor": "#FF9800",
-					"borderRadius":    16.0,
-					"margin":          16.0,
-					"gradient": map[string]interface{}{
-						"colors": []string{"#FF9800", "#FFC107"},
-					},
-				},
-				"action": map[string]interface{}{
-					"type":  "navigate",
-					"route": "/deals",
+			"style": map[string]interface{}{
+				"backgroundColor": "$primary",
+				"gradient": map[string]interface{}{
+					"colors": []string{"$primary", "$accent"},
 				},
 			},
-			map[string]interface{}{
-				"id":   "categories",
-				"type": "category_chips",
-				"props": map[string]interface{}{
-					"categories": []interface{}{
-						map[string]interface{}{"id": "1", "name": "New In"},
-						map[string]interface{}{"id": "2", "name": "Trending"},
-						map[string]interface{}{"id": "3", "name": "Sale"},
-						map[string]interface{}{"id": "4", "name": "Premium"},
-					},
-					"selectedId": "1",
-				},
-				"style": map[string]interface{}{
-					"padding":       16.0,
-					"selectedColor": "#FF9800",
-				},
+			"action": map[string]interface{}{
+				"type":  "navigate",
+				"route": "/deals",
 			},
-			map[string]interface{}{
-				"id":   "featured-products",
-				"type": "product_carousel",
-				"props": map[string]interface{}{
-					"products":  getMorningProducts(),
-					"height":    320.0,
-					"cardWidth": 200.0,
-				},
-				"style": map[string]interface{}{
-					"padding":        16.0,
-					"spacing":        12.0,
-					"borderRadius":   12.0,
-					"imageHeight":    180.0,
-					"showDiscount":   true,
-					"showRating":     true,
-					"showFavorite":   true,
-					"titleSize":      16.0,
-					"priceSize":      18.0,
-					"priceColor":     "#FF9800",
-					"elevation":      2.0,
-					"contentPadding": 12.0,
-				},
+		}),
+		map[string]interface{}{
+			"id":   "categories",
+			"type": "category_chips",
+			"props": map[string]interface{}{
+				"categories": []interface{}{
+					map[string]interface{}{"id": "1", "name": msg("category.new_in")},
+					map[string]interface{}{"id": "2", "name": msg("category.trending")},
+					map[string]interface{}{"id": "3", "name": msg("category.sale")},
+					map[string]interface{}{"id": "4", "name": msg("category.premium")},
+				},
+				"selectedId": "1",
 			},
-			map[string]interface{}{
-				"id":   "review",
-				"type": "testimonial_card",
-				"props": map[string]interface{}{
-					"name":     "Sarah M.",
-					"subtitle": "Verified Buyer",
-					"text":     "Love shopping here in the morning! The early bird deals are amazing and delivery is always on time.",
-					"rating":   5.0,
-				},
-				"style": map[string]interface{}{
-					"margin": 16.0,
+			"style": map[string]interface{}{
+				"padding":       16.0,
+				"selectedColor": "$primary",
+			},
+		},
+		recentlyViewedSection(),
+		fragment("product-carousel", map[string]interface{}{
+			"id": "featured-products",
+			"props": map[string]interface{}{
+				"products": getMorningProducts(),
+				"data_source": map[string]interface{}{
+					"strategy": "trending",
+					"limit":    6,
 				},
 			},
+		}),
+		map[string]interface{}{
+			"id":   "review",
+			"type": "testimonial_card",
+			"flag": "testimonials",
+			"props": map[string]interface{}{
+				"name":     "Sarah M.",
+				"subtitle": msg("morning.review.subtitle"),
+				"text":     msg("morning.review.text"),
+				"rating":   5.0,
+			},
+			"style": map[string]interface{}{
+				"margin": 16.0,
+			},
 		},
-		"navigation": getNavigationConfig("/", "morning"),
-		"metadata":   getMetadata("morning"),
-	}
+	})
 }
 
 // 🔥 FLASH SALE MODE (12PM - 2PM): Maximum Urgency
 func getFlashSaleConfig() map[string]interface{} {
-	return map[string]interface{}{
-		"screen_id":   "home",
-		"layout_type": "scroll",
-		"theme": map[string]interface{}{
-			"is_dark_mode":     false,
-			"primary_color":    "#FF4757",
-			"background_color": "#FFFFFF",
-			"accent_color":     "#FF6B6B",
-			"font_sizes": map[string]float64{
-				"headline": 28.0,
-				"title":    18.0,
-				"body":     14.0,
-				"caption":  11.0,
-			},
-			"border_radius": 8.0,
-			"spacing": map[string]float64{
-				"xs": 2.0,
-				"sm": 4.0,
-				"md": 8.0,
-				"lg": 12.0,
-				"xl": 16.0,
+	return homeLayout("flash_sale", []interface{}{
+		map[string]interface{}{
+			"id":   "flash-countdown",
+			"type": "countdown_timer",
+			"props": map[string]interface{}{
+				"label":     msg("flash.countdown.label"),
+				"ends_with": "flash_sale",
+				"showIcon":  true,
 			},
-		},
-		"components": []interface{}{
-			map[string]interface{}{
-				"id":   "flash-countdown",
-				"type": "countdown_timer",
-				"props": map[string]interface{}{
-					"label":    "⚡ FLASH SALE ENDS IN",
-					"end_time": "1:47:23",
-					"showIcon": true,
-				},
-				"style": map[string]interface{}{
-					"backgroundColor": "#FF4757",
-					"padding":         14.0,
-					"borderRadius":    0.0,
-					"fontSize":        18.0,
-					"gradient": map[string]interface{}{
-						"colors": []string{"#FF4757", "#FF6B6B"},
-					},
+			"style": map[string]interface{}{
+				"backgroundColor": "$primary",
+				"padding":         14.0,
+				"borderRadius":    0.0,
+				"fontSize":        18.0,
+				"gradient": map[string]interface{}{
+					"colors": []string{"$primary", "$accent"},
 				},
 			},
-			map[string]interface{}{
-				"id":   "promo-row",
-				"type": "row",
-				"props": map[string]interface{}{
-					"alignment": "spaceEvenly",
-				},
-				"style": map[string]interface{}{
-					"padding": 12.0,
+		},
+		map[string]interface{}{
+			"id":   "promo-row",
+			"type": "row",
+			"flag": "flash_promo_badges",
+			"props": map[string]interface{}{
+				"alignment": "spaceEvenly",
+			},
+			"style": map[string]interface{}{
+				"padding": 12.0,
+			},
+			"children": []interface{}{
+				map[string]interface{}{
+					"id":   "badge1",
+					"type": "promo_badge",
+					"props": map[string]interface{}{
+						"text": msg("flash.badge.discount"),
+					},
+					"style": map[string]interface{}{
+						"backgroundColor": "$primary",
+						"paddingX":        16.0,
+						"paddingY":        8.0,
+						"borderRadius":    20.0,
+						"fontSize":        12.0,
+					},
 				},
-				"children": []interface{}{
-					map[string]interface{}{
-						"id":   "badge1",
-						"type": "promo_badge",
-						"props": map[string]interface{}{
-							"text": "UP TO 70% OFF",
-						},
-						"style": map[string]interface{}{
-							"backgroundColor": "#FF4757",
-							"paddingX":        16.0,
-							"paddingY":        8.0,
-							"borderRadius":    20.0,
-							"fontSize":        12.0,
-						},
+				map[string]interface{}{
+					"id":   "badge2",
+					"type": "promo_badge",
+					"props": map[string]interface{}{
+						"text": msg("flash.badge.shipping"),
 					},
-					map[string]interface{}{
-						"id":   "badge2",
-						"type": "promo_badge",
-						"props": map[string]interface{}{
-							"text": "FREE SHIPPING",
-						},
-						"style": map[string]interface{}{
-							"backgroundColor": "#4CAF50",
-							"paddingX":        16.0,
-							"paddingY":        8.0,
-							"borderRadius":    20.0,
-							"fontSize":        12.0,
-						},
+					"style": map[string]interface{}{
+						"backgroundColor": "$success",
+						"paddingX":        16.0,
+						"paddingY":        8.0,
+						"borderRadius":    20.0,
+						"fontSize":        12.0,
 					},
 				},
 			},
-			map[string]interface{}{
-				"id":   "flash-products",
-				"type": "product_grid",
-				"props": map[string]interface{}{
-					"columns":     2,
-					"spacing":     8.0,
-					"aspectRatio": 0.68,
-					"products":    getFlashSaleProducts(),
-				},
-				"style": map[string]interface{}{
-					"imageHeight":    130.0,
-					"borderRadius":   8.0,
-					"showDiscount":   true,
-					"showRating":     false,
-					"showFavorite":   false,
-					"titleSize":      13.0,
-					"priceSize":      16.0,
-					"priceColor":     "#FF4757",
-					"elevation":      1.0,
-					"contentPadding": 8.0,
-				},
+		},
+		fragment("standard-product-grid", map[string]interface{}{
+			"id": "flash-products",
+			"props": map[string]interface{}{
+				"spacing":     8.0,
+				"aspectRatio": 0.68,
+				"products":    getFlashSaleProducts(),
 			},
-			map[string]interface{}{
-				"id":   "urgency",
-				"type": "container",
-				"style": map[string]interface{}{
-					"backgroundColor": "#FFF3CD",
-					"padding":         16.0,
-					"margin":          8.0,
-					"borderRadius":    8.0,
-					"borderColor":     "#FFB800",
-					"borderWidth":     2.0,
-				},
-				"children": []interface{}{
-					map[string]interface{}{
-						"id":   "urgency-text",
-						"type": "header",
-						"props": map[string]interface{}{
-							"title":     "⚠️ Limited Stock!",
-							"subtitle":  "Items selling fast. Don't miss out!",
-							"alignment": "center",
-						},
-						"style": map[string]interface{}{
-							"fontSize":     16.0,
-							"color":        "#856404",
-							"padding":      0.0,
-							"subtitleSize": 13.0,
-						},
+			"style": map[string]interface{}{
+				"imageHeight":    130.0,
+				"borderRadius":   8.0,
+				"showRating":     false,
+				"showFavorite":   false,
+				"titleSize":      13.0,
+				"priceSize":      16.0,
+				"elevation":      1.0,
+				"contentPadding": 8.0,
+			},
+		}),
+		map[string]interface{}{
+			"id":   "urgency",
+			"type": "container",
+			"style": map[string]interface{}{
+				"backgroundColor": "#FFF3CD",
+				"padding":         16.0,
+				"margin":          8.0,
+				"borderRadius":    8.0,
+				"borderColor":     "$warning",
+				"borderWidth":     2.0,
+			},
+			"children": []interface{}{
+				map[string]interface{}{
+					"id":   "urgency-text",
+					"type": "header",
+					"props": map[string]interface{}{
+						"title":     msg("flash.urgency.title"),
+						"subtitle":  msg("flash.urgency.subtitle"),
+						"alignment": "center",
+					},
+					"style": map[string]interface{}{
+						"fontSize":     16.0,
+						"color":        "#856404",
+						"padding":      0.0,
+						"subtitleSize": 13.0,
 					},
 				},
 			},
 		},
-		"navigation": getNavigationConfig("/", "flash_sale"),
-		"metadata":   getMetadata("flash_sale"),
-	}
+	})
 }
 
 // 🌆 AFTERNOON MODE (2PM - 5PM): Productive Discovery
 func getAfternoonModeConfig() map[string]interface{} {
-	return map[string]interface{}{
-		"screen_id":   "home",
-		"layout_type": "scroll",
-		"theme": map[string]interface{}{
-			"is_dark_mode":     false,
-			"primary_color":    "#00BCD4",
-			"background_color": "#F0F8FF",
-			"accent_color":     "#00ACC1",
-			"font_sizes": map[string]float64{
-				"headline": 30.0,
-				"title":    19.0,
-				"body":     15.0,
-				"caption":  12.0,
-			},
-			"border_radius": 12.0,
-			"spacing": map[string]float64{
-				"xs": 4.0,
-				"sm": 8.0,
-				"md": 16.0,
-				"lg": 24.0,
-				"xl": 32.0,
+	return homeLayout("afternoon", []interface{}{
+		fragment("section-header", map[string]interface{}{
+			"id": "afternoon-header",
+			"props": map[string]interface{}{
+				"title":    msg("afternoon.header.title"),
+				"subtitle": msg("afternoon.header.subtitle"),
 			},
-		},
-		"components": []interface{}{
-			map[string]interface{}{
-				"id":   "afternoon-header",
-				"type": "header",
-				"props": map[string]interface{}{
-					"title":     "Discover Something New",
-					"subtitle":  "Curated picks just for you",
-					"alignment": "left",
-				},
-				"style": map[string]interface{}{
-					"padding":  18.0,
-					"fontSize": 30.0,
-					"color":    "#00BCD4",
-				},
+			"style": map[string]interface{}{
+				"padding": 18.0,
 			},
-			map[string]interface{}{
-				"id":   "categories-horizontal",
-				"type": "horizontal_list",
-				"props": map[string]interface{}{
-					"height":    140.0,
-					"itemWidth": 130.0,
-					"items": []interface{}{
-						map[string]interface{}{
-							"id":        "cat1",
-							"title":     "Electronics",
-							"image_url": "https://via.placeholder.com/130x100/00BCD4/FFFFFF?text=Electronics",
-						},
-						map[string]interface{}{
-							"id":        "cat2",
-							"title":     "Fashion",
-							"image_url": "https://via.placeholder.com/130x100/00ACC1/FFFFFF?text=Fashion",
-						},
-						map[string]interface{}{
-							"id":        "cat3",
-							"title":     "Home",
-							"image_url": "https://via.placeholder.com/130x100/0097A7/FFFFFF?text=Home",
-						},
-						map[string]interface{}{
-							"id":        "cat4",
-							"title":     "Sports",
-							"image_url": "https://via.placeholder.com/130x100/00838F/FFFFFF?text=Sports",
-						},
+		}),
+		map[string]interface{}{
+			"id":   "categories-horizontal",
+			"type": "horizontal_list",
+			"props": map[string]interface{}{
+				"height":    140.0,
+				"itemWidth": 130.0,
+				"items": []interface{}{
+					map[string]interface{}{
+						"id":        "cat1",
+						"title":     msg("category.electronics"),
+						"image_url": "https://via.placeholder.com/130x100/00BCD4/FFFFFF?text=Electronics",
+					},
+					map[string]interface{}{
+						"id":        "cat2",
+						"title":     msg("category.fashion"),
+						"image_url": "https://via.placeholder.com/130x100/00ACC1/FFFFFF?text=Fashion",
+					},
+					map[string]interface{}{
+						"id":        "cat3",
+						"title":     msg("category.home"),
+						"image_url": "https://via.placeholder.com/130x100/0097A7/FFFFFF?text=Home",
+					},
+					map[string]interface{}{
+						"id":        "cat4",
+						"title":     msg("category.sports"),
+						"image_url": "https://via.placeholder.com/130x100/00838F/FFFFFF?text=Sports",
 					},
-				},
-				"style": map[string]interface{}{
-					"padding": 16.0,
-					"spacing": 12.0,
-				},
-			},
-			map[string]interface{}{
-				"id":   "afternoon-banner",
-				"type": "banner",
-				"props": map[string]interface{}{
-					"title":      "Midday Break Deals",
-					"subtitle":   "Take a break, save big",
-					"buttonText": "Browse Deals",
-					"height":     200.0,
-					"alignment":  "center",
-				},
-				"style": map[string]interface{}{
-					"backgroundColor": "#00BCD4",
-					"borderRadius":    14.0,
-					"margin":          16.0,
 				},
 			},
-			map[string]interface{}{
-				"id":   "afternoon-products",
-				"type": "product_grid",
-				"props": map[string]interface{}{
-					"columns":     2,
-					"spacing":     14.0,
-					"aspectRatio": 0.75,
-					"products":    getAfternoonProducts(),
-				},
-				"style": map[string]interface{}{
-					"imageHeight":    200.0,
-					"borderRadius":   12.0,
-					"showDiscount":   true,
-					"showRating":     true,
-					"showFavorite":   true,
-					"titleSize":      17.0,
-					"priceSize":      19.0,
-					"priceColor":     "#00BCD4",
-					"elevation":      2.0,
-					"contentPadding": 12.0,
-				},
+			"style": map[string]interface{}{
+				"padding": 16.0,
+				"spacing": 12.0,
 			},
 		},
-		"navigation": getNavigationConfig("/", "afternoon"),
-		"metadata":   getMetadata("afternoon"),
-	}
+		fragment("promo-banner", map[string]interface{}{
+			"id": "afternoon-banner",
+			"props": map[string]interface{}{
+				"title":      msg("afternoon.banner.title"),
+				"subtitle":   msg("afternoon.banner.subtitle"),
+				"buttonText": msg("common.browse_deals"),
+				"alignment":  "center",
+			},
+			"style": map[string]interface{}{
+				"backgroundColor": "$primary",
+				"borderRadius":    14.0,
+			},
+		}),
+		recentlyViewedSection(),
+		fragment("standard-product-grid", map[string]interface{}{
+			"id": "afternoon-products",
+			"props": map[string]interface{}{
+				"spacing":  14.0,
+				"products": getAfternoonProducts(),
+			},
+			"style": map[string]interface{}{
+				"titleSize": 17.0,
+				"priceSize": 19.0,
+			},
+		}),
+	})
 }
 
 // 🌙 EVENING MODE (5PM - 8PM): Curated Premium
 func getEveningConfig() map[string]interface{} {
-	return map[string]interface{}{
-		"screen_id":   "home",
-		"layout_type": "scroll",
-		"theme": map[string]interface{}{
-			"is_dark_mode":     false,
-			"primary_color":    "#6C5CE7",
-			"background_color": "#FAF9F6",
-			"accent_color":     "#A29BFE",
-			"font_sizes": map[string]float64{
-				"headline": 36.0,
-				"title":    22.0,
-				"body":     17.0,
-				"caption":  13.0,
-			},
-			"border_radius": 16.0,
-			"spacing": map[string]float64{
-				"xs": 4.0,
-				"sm": 8.0,
-				"md": 18.0,
-				"lg": 28.0,
-				"xl": 40.0,
+	return homeLayout("evening", []interface{}{
+		fragment("section-header", map[string]interface{}{
+			"id": "evening-header",
+			"props": map[string]interface{}{
+				"title":     msg("evening.header.title"),
+				"subtitle":  msg("evening.header.subtitle"),
+				"alignment": "center",
+				"showIcon":  true,
+				"icon":      "star",
 			},
-		},
-		"components": []interface{}{
-			map[string]interface{}{
-				"id":   "evening-header",
-				"type": "header",
-				"props": map[string]interface{}{
-					"title":     "Evening Selections",
-					"subtitle":  "Curated with care for tonight",
-					"alignment": "center",
-					"showIcon":  true,
-					"icon":      "star",
-				},
-				"style": map[string]interface{}{
-					"padding":         20.0,
-					"fontSize":        36.0,
-					"color":           "#6C5CE7",
-					"subtitleSize":    18.0,
-					"subtitleColor":   "#A29BFE",
-					"subtitleSpacing": 10.0,
-					"iconSize":        32.0,
-					"letterSpacing":   0.5,
-				},
+			"style": map[string]interface{}{
+				"padding":         20.0,
+				"subtitleSize":    18.0,
+				"subtitleColor":   "$accent",
+				"subtitleSpacing": 10.0,
+				"iconSize":        32.0,
+				"letterSpacing":   0.5,
 			},
-			map[string]interface{}{
-				"id":   "evening-banner",
-				"type": "animated_banner",
-				"props": map[string]interface{}{
-					"title":      "Sunset Collection",
-					"subtitle":   "Premium pieces for your evening",
-					"buttonText": "Explore",
-					"height":     300.0,
-					"duration":   1000,
-				},
-				"style": map[string]interface{}{
-					"backgroundColor": "#6C5CE7",
-					"borderRadius":    18.0,
-					"margin":          20.0,
-					"titleSize":       32.0,
-					"subtitleSize":    18.0,
-					"gradient": map[string]interface{}{
-						"colors": []string{"#6C5CE7", "#A29BFE"},
-					},
-				},
+		}),
+		map[string]interface{}{
+			"id":   "evening-banner",
+			"type": "animated_banner",
+			"props": map[string]interface{}{
+				"title":      msg("evening.banner.title"),
+				"subtitle":   msg("evening.banner.subtitle"),
+				"buttonText": msg("common.explore"),
+				"height":     300.0,
+				"duration":   1000,
 			},
-			map[string]interface{}{
-				"id":   "featured-carousel",
-				"type": "product_carousel",
-				"props": map[string]interface{}{
-					"products":  getEveningProducts(),
-					"height":    350.0,
-					"cardWidth": 240.0,
-				},
-				"style": map[string]interface{}{
-					"padding":        20.0,
-					"spacing":        16.0,
-					"borderRadius":   16.0,
-					"imageHeight":    220.0,
-					"showDiscount":   false,
-					"showRating":     true,
-					"showFavorite":   true,
-					"titleSize":      19.0,
-					"priceSize":      22.0,
-					"priceColor":     "#6C5CE7",
-					"elevation":      4.0,
-					"contentPadding": 14.0,
+			"style": map[string]interface{}{
+				"backgroundColor": "$primary",
+				"borderRadius":    18.0,
+				"margin":          20.0,
+				"titleSize":       32.0,
+				"subtitleSize":    18.0,
+				"gradient": map[string]interface{}{
+					"colors": []string{"$primary", "$accent"},
 				},
 			},
-			map[string]interface{}{
-				"id":   "evening-grid",
-				"type": "product_grid",
-				"props": map[string]interface{}{
-					"columns":     2,
-					"spacing":     18.0,
-					"aspectRatio": 0.8,
-					"products":    getEveningProducts(),
-				},
-				"style": map[string]interface{}{
-					"imageHeight":    220.0,
-					"borderRadius":   16.0,
-					"showDiscount":   false,
-					"showRating":     true,
-					"showFavorite":   true,
-					"titleSize":      18.0,
-					"priceSize":      20.0,
-					"priceColor":     "#6C5CE7",
-					"elevation":      3.0,
-					"contentPadding": 14.0,
+		},
+		fragment("product-carousel", map[string]interface{}{
+			"id": "featured-carousel",
+			"props": map[string]interface{}{
+				"products":  getEveningProducts(),
+				"height":    350.0,
+				"cardWidth": 240.0,
+				"data_source": map[string]interface{}{
+					"strategy": "co_purchased",
+					"limit":    4,
 				},
 			},
-		},
-		"navigation": getNavigationConfig("/", "evening"),
-		"metadata":   getMetadata("evening"),
-	}
+			"style": map[string]interface{}{
+				"padding":        20.0,
+				"spacing":        16.0,
+				"borderRadius":   16.0,
+				"imageHeight":    220.0,
+				"showDiscount":   false,
+				"titleSize":      19.0,
+				"priceSize":      22.0,
+				"elevation":      4.0,
+				"contentPadding": 14.0,
+			},
+		}),
+		recentlyViewedSection(),
+		fragment("standard-product-grid", map[string]interface{}{
+			"id": "evening-grid",
+			"props": map[string]interface{}{
+				"spacing":     18.0,
+				"aspectRatio": 0.8,
+				"products":    getEveningProducts(),
+			},
+			"style": map[string]interface{}{
+				"imageHeight":    220.0,
+				"borderRadius":   16.0,
+				"showDiscount":   false,
+				"titleSize":      18.0,
+				"priceSize":      20.0,
+				"elevation":      3.0,
+				"contentPadding": 14.0,
+			},
+		}),
+	})
 }
 
 // 🌙 NIGHT MODE (8PM - 12AM): Boutique Experience
 func getNightModeConfig() map[string]interface{} {
-	return map[string]interface{}{
-		"screen_id":   "home",
-		"layout_type": "scroll",
-		"theme": map[string]interface{}{
-			"is_dark_mode":     true,
-			"primary_color":    "#1A1A1A",
-			"background_color": "#0A0A0A",
-			"accent_color":     "#FFD700",
-			"font_sizes": map[string]float64{
-				"headline": 40.0,
-				"title":    28.0,
-				"body":     18.0,
-				"caption":  14.0,
-			},
-			"border_radius": 20.0,
-			"spacing": map[string]float64{
-				"xs": 4.0,
-				"sm": 8.0,
-				"md": 20.0,
-				"lg": 32.0,
-				"xl": 48.0,
+	return homeLayout("night", []interface{}{
+		fragment("promo-banner", map[string]interface{}{
+			"id": "night-hero",
+			"props": map[string]interface{}{
+				"title":     msg("night.hero.title"),
+				"subtitle":  msg("night.hero.subtitle"),
+				"height":    400.0,
+				"alignment": "centerLeft",
 			},
-		},
-		"components": []interface{}{
-			map[string]interface{}{
-				"id":   "night-hero",
-				"type": "banner",
-				"props": map[string]interface{}{
-					"title":     "Midnight Collection",
-					"subtitle":  "Curated elegance for the night",
-					"height":    400.0,
-					"alignment": "centerLeft",
-				},
-				"style": map[string]interface{}{
-					"backgroundColor": "#1A1A2E",
-					"borderRadius":    20.0,
-					"margin":          24.0,
-					"titleSize":       40.0,
-					"subtitleSize":    20.0,
-					"contentPadding":  32.0,
-				},
+			"style": map[string]interface{}{
+				"backgroundColor": "$surface",
+				"borderRadius":    20.0,
+				"margin":          24.0,
+				"titleSize":       40.0,
+				"subtitleSize":    20.0,
+				"contentPadding":  32.0,
 			},
-			map[string]interface{}{
-				"id":    "spacer",
-				"type":  "spacer",
-				"props": map[string]interface{}{"height": 32.0},
+		}),
+		map[string]interface{}{
+			"id":    "spacer",
+			"type":  "spacer",
+			"props": map[string]interface{}{"height": 32.0},
+		},
+		fragment("standard-product-grid", map[string]interface{}{
+			"id": "night-products",
+			"props": map[string]interface{}{
+				"columns":     1,
+				"spacing":     24.0,
+				"aspectRatio": 1.2,
+				"products":    getNightProducts(),
 			},
-			map[string]interface{}{
-				"id":   "night-products",
-				"type": "product_grid",
-				"props": map[string]interface{}{
-					"columns":     1,
-					"spacing":     24.0,
-					"aspectRatio": 1.2,
-					"products":    getNightProducts(),
-				},
-				"style": map[string]interface{}{
-					"imageHeight":    300.0,
-					"borderRadius":   20.0,
-					"showDiscount":   false,
-					"showRating":     false,
-					"showFavorite":   true,
-					"titleSize":      24.0,
-					"priceSize":      28.0,
-					"priceColor":     "#FFD700",
-					"elevation":      2.0,
-					"contentPadding": 16.0,
-				},
+			"style": map[string]interface{}{
+				"imageHeight":    300.0,
+				"borderRadius":   20.0,
+				"showDiscount":   false,
+				"showRating":     false,
+				"titleSize":      24.0,
+				"priceSize":      28.0,
+				"priceColor":     "$accent",
+				"contentPadding": 16.0,
 			},
-		},
-		"navigation": getNavigationConfig("/", "night"),
-		"metadata":   getMetadata("night"),
-	}
+		}),
+	})
 }
 
 // ☀️ DAY MODE (9AM - 12PM): Standard Shopping
 func getDayModeConfig() map[string]interface{} {
-	return map[string]interface{}{
-		"screen_id":   "home",
-		"layout_type": "scroll",
-		"theme": map[string]interface{}{
-			"is_dark_mode":     false,
-			"primary_color":    "#2C3E50",
-			"background_color": "#FFFFFF",
-			"accent_color":     "#3498DB",
-			"font_sizes": map[string]float64{
-				"headline": 32.0,
-				"title":    20.0,
-				"body":     16.0,
-				"caption":  12.0,
-			},
-			"border_radius": 12.0,
-			"spacing": map[string]float64{
-				"xs": 4.0,
-				"sm": 8.0,
-				"md": 16.0,
-				"lg": 24.0,
-				"xl": 32.0,
+	return homeLayout("day", standardHomeComponents())
+}
+
+// standardHomeComponents is the base home layout; day mode uses it as is
+// and other modes extend it
+func standardHomeComponents() []interface{} {
+	return []interface{}{
+		fragment("section-header", map[string]interface{}{
+			"id": "header",
+			"props": map[string]interface{}{
+				"title":    msg("home.welcome.title"),
+				"subtitle": msg("home.welcome.subtitle"),
 			},
-		},
-		"components": []interface{}{
-			map[string]interface{}{
-				"id":   "header",
-				"type": "header",
-				"props": map[string]interface{}{
-					"title":     "Welcome Back",
-					"subtitle":  "What are you shopping for today?",
-					"alignment": "left",
-				},
-				"style": map[string]interface{}{
-					"padding":  16.0,
-					"fontSize": 32.0,
-					"color":    "#2C3E50",
-				},
+			"visible_when": map[string]interface{}{
+				"not": map[string]interface{}{"segments": []string{"returning_buyer"}},
 			},
-			map[string]interface{}{
-				"id":   "promo-banner",
-				"type": "banner",
-				"props": map[string]interface{}{
-					"title":     "New Arrivals",
-					"subtitle":  "Fresh styles just in",
-					"height":    200.0,
-					"alignment": "centerLeft",
-				},
-				"style": map[string]interface{}{
-					"backgroundColor": "#3498DB",
-					"borderRadius":    16.0,
-					"margin":          16.0,
-				},
+		}),
+		fragment("section-header", map[string]interface{}{
+			"id": "welcome-back-header",
+			"props": map[string]interface{}{
+				"title":    msg("home.welcome_back.title"),
+				"subtitle": msg("home.welcome_back.subtitle"),
 			},
-			map[string]interface{}{
-				"id":   "products",
-				"type": "product_grid",
-				"props": map[string]interface{}{
-					"columns":     2,
-					"spacing":     16.0,
-					"aspectRatio": 0.75,
-					"products":    getDayProducts(),
-				},
-				"style": map[string]interface{}{
-					"imageHeight":    200.0,
-					"borderRadius":   12.0,
-					"showDiscount":   true,
-					"showRating":     true,
-					"showFavorite":   true,
-					"titleSize":      16.0,
-					"priceSize":      18.0,
-					"priceColor":     "#2C3E50",
-					"elevation":      2.0,
-					"contentPadding": 12.0,
-				},
+			"visible_when": map[string]interface{}{
+				"segments": []string{"returning_buyer"},
 			},
-		},
-		"navigation": getNavigationConfig("/", "day"),
-		"metadata":   getMetadata("day"),
+		}),
+		fragment("promo-banner", map[string]interface{}{
+			"id": "promo-banner",
+			"props": map[string]interface{}{
+				"title":     msg("home.new_arrivals.title"),
+				"subtitle":  msg("home.new_arrivals.subtitle"),
+				"alignment": "centerLeft",
+			},
+			"style": map[string]interface{}{
+				"backgroundColor": "$accent",
+			},
+		}),
+		fragment("promo-banner", map[string]interface{}{
+			"id": "cart-reminder",
+			"props": map[string]interface{}{
+				"title":      msg("home.cart_reminder.title"),
+				"subtitle":   msg("home.cart_reminder.subtitle"),
+				"buttonText": msg("common.view_cart"),
+				"height":     120.0,
+			},
+			"style": map[string]interface{}{
+				"backgroundColor": "$primary",
+				"borderRadius":    12.0,
+			},
+			"action": map[string]interface{}{
+				"type":  "navigate",
+				"route": "/cart",
+			},
+			"visible_when": map[string]interface{}{
+				"cart_non_empty": true,
+			},
+		}),
+		recentlyViewedSection(),
+		fragment("standard-product-grid", map[string]interface{}{
+			"id": "products",
+			"props": map[string]interface{}{
+				"products": getDayProducts(),
+			},
+		}),
 	}
 }
 
@@ -989,7 +817,7 @@ func getProductScreenConfig(mode string, productID string) map[string]interface{
 				"type": "container",
 				"style": map[string]interface{}{
 					"padding":         24.0,
-					"backgroundColor": "#FFFFFF",
+					"backgroundColor": "$surface",
 				},
 				"children": []interface{}{
 					map[string]interface{}{
@@ -1026,21 +854,22 @@ func getProductScreenConfig(mode string, productID string) map[string]interface{
 						"style": map[string]interface{}{
 							"fontSize":   36.0,
 							"fontWeight": "bold",
-							"color":      "#2C3E50",
+							"color":      "$primary",
 							"padding":    8.0,
 						},
 					},
+					stockBadge(productID),
 					map[string]interface{}{
 						"id":   "buy-button",
 						"type": "button",
 						"props": map[string]interface{}{
-							"label":     "Add to Cart",
+							"label":     msg("product.add_to_cart"),
 							"variant":   "primary",
 							"fullWidth": true,
 							"icon":      "cart",
 						},
 						"style": map[string]interface{}{
-							"backgroundColor": "#4CAF50",
+							"backgroundColor": "$success",
 							"paddingY":        18.0,
 							"borderRadius":    12.0,
 							"margin":          16.0,
@@ -1064,20 +893,20 @@ func getCartScreenConfig(mode string) map[string]interface{} {
 				"id":   "cart-header",
 				"type": "header",
 				"props": map[string]interface{}{
-					"title":    "Shopping Cart",
-					"subtitle": "3 items",
+					"title":    msg("cart.title"),
+					"subtitle": `{{cart.count | t "cart.item_count"}}`,
 				},
 			},
 			map[string]interface{}{
 				"id":   "checkout-button",
 				"type": "button",
 				"props": map[string]interface{}{
-					"label":     "Proceed to Checkout - $589.97",
+					"label":     msg("cart.checkout"),
 					"variant":   "primary",
 					"fullWidth": true,
 				},
 				"style": map[string]interface{}{
-					"backgroundColor": "#4CAF50",
+					"backgroundColor": "$success",
 					"margin":          16.0,
 				},
 			},
@@ -1097,7 +926,7 @@ func getSearchScreenConfig(mode string) map[string]interface{} {
 				"id":   "search-input",
 				"type": "search_bar",
 				"props": map[string]interface{}{
-					"placeholder": "Search products...",
+					"placeholder": msg("search.placeholder"),
 					"showFilter":  true,
 				},
 			},
@@ -1151,53 +980,6 @@ func getProfileScreenConfig(mode string) map[string]interface{} {
 
 // ==================== HELPER FUNCTIONS ====================
 
-func getThemeForMode(mode string) map[string]interface{} {
-	switch mode {
-	case "night", "late_night":
-		return map[string]interface{}{
-			"is_dark_mode":     true,
-			"primary_color":    "#1A1A1A",
-			"background_color": "#0A0A0A",
-			"accent_color":     "#FFD700",
-		}
-	case "morning":
-		return map[string]interface{}{
-			"is_dark_mode":     false,
-			"primary_color":    "#FF9800",
-			"background_color": "#FFFBF5",
-			"accent_color":     "#FFC107",
-		}
-	case "flash_sale":
-		return map[string]interface{}{
-			"is_dark_mode":     false,
-			"primary_color":    "#FF4757",
-			"background_color": "#FFFFFF",
-			"accent_color":     "#FF6B6B",
-		}
-	case "afternoon":
-		return map[string]interface{}{
-			"is_dark_mode":     false,
-			"primary_color":    "#00BCD4",
-			"background_color": "#F0F8FF",
-			"accent_color":     "#00ACC1",
-		}
-	case "evening":
-		return map[string]interface{}{
-			"is_dark_mode":     false,
-			"primary_color":    "#6C5CE7",
-			"background_color": "#FAF9F6",
-			"accent_color":     "#A29BFE",
-		}
-	default:
-		return map[string]interface{}{
-			"is_dark_mode":     false,
-			"primary_color":    "#2C3E50",
-			"background_color": "#FFFFFF",
-			"accent_color":     "#3498DB",
-		}
-	}
-}
-
 func getMetadata(mode string) map[string]interface{} {
 	return map[string]interface{}{
 		"mode":         mode,
@@ -1498,25 +1280,25 @@ func getMorningStories() []interface{} {
 	return []interface{}{
 		map[string]interface{}{
 			"id":        "s1",
-			"name":      "New In",
+			"name":      msg("category.new_in"),
 			"viewed":    false,
 			"image_url": "https://via.placeholder.com/70/FF9800/FFFFFF?text=New",
 		},
 		map[string]interface{}{
 			"id":        "s2",
-			"name":      "Sale",
+			"name":      msg("category.sale"),
 			"viewed":    false,
 			"image_url": "https://via.placeholder.com/70/FFC107/FFFFFF?text=Sale",
 		},
 		map[string]interface{}{
 			"id":        "s3",
-			"name":      "Trending",
+			"name":      msg("category.trending"),
 			"viewed":    true,
 			"image_url": "https://via.placeholder.com/70/FF9800/FFFFFF?text=Hot",
 		},
 		map[string]interface{}{
 			"id":        "s4",
-			"name":      "Deals",
+			"name":      msg("category.deals"),
 			"viewed":    false,
 			"image_url": "https://via.placeholder.com/70/FFC107/FFFFFF?text=Deals",
 		},
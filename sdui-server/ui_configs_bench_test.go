@@ -0,0 +1,104 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// go test -run '^$' -bench UiConfig -benchmem
+
+func benchmarkUiConfig(b *testing.B, target, acceptEncoding string, signedIn, cached bool) {
+	quietLogs(b)
+
+	renderCache.mu.Lock()
+	previousEnabled, previousEntries := renderCache.enabled, renderCache.entries
+	renderCache.enabled = cached
+	renderCache.entries = make(map[renderKey]*renderEntry)
+	renderCache.mu.Unlock()
+	b.Cleanup(func() {
+		renderCache.mu.Lock()
+		renderCache.enabled, renderCache.entries = previousEnabled, previousEntries
+		renderCache.mu.Unlock()
+	})
+
+	handler := withAuth(http.HandlerFunc(handleUiConfig))
+	token := ""
+	if signedIn {
+		previousCart := userData.cart("guest_bench")
+		userData.addToCart("guest_bench", "prod_2", 2)
+		b.Cleanup(func() {
+			userData.mu.Lock()
+			defer userData.mu.Unlock()
+			if len(previousCart) == 0 {
+				delete(userData.carts, "guest_bench")
+			} else {
+				userData.carts["guest_bench"] = previousCart
+			}
+		})
+		token, _ = issueToken("guest_bench", "access", true, time.Hour)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			b.Fatalf("status %d", rec.Code)
+		}
+	}
+}
+
+func BenchmarkUiConfigHome(b *testing.B) {
+	benchmarkUiConfig(b, "/api/ui-config?screen=/", "", false, true)
+}
+
+func BenchmarkUiConfigHomeSignedIn(b *testing.B) {
+	benchmarkUiConfig(b, "/api/ui-config?screen=/", "", true, true)
+}
+
+func BenchmarkUiConfigProduct(b *testing.B) {
+	benchmarkUiConfig(b, "/api/ui-config?screen=/product&id=prod_3", "", false, true)
+}
+
+func BenchmarkUiConfigCart(b *testing.B) {
+	benchmarkUiConfig(b, "/api/ui-config?screen=/cart", "", true, true)
+}
+
+func BenchmarkUiConfigHomeUncached(b *testing.B) {
+	benchmarkUiConfig(b, "/api/ui-config?screen=/", "", false, false)
+}
+
+func BenchmarkUiConfigHomeSignedInUncached(b *testing.B) {
+	benchmarkUiConfig(b, "/api/ui-config?screen=/", "", true, false)
+}
+
+func BenchmarkUiConfigProductUncached(b *testing.B) {
+	benchmarkUiConfig(b, "/api/ui-config?screen=/product&id=prod_3", "", false, false)
+}
+
+func BenchmarkUiConfigCartUncached(b *testing.B) {
+	benchmarkUiConfig(b, "/api/ui-config?screen=/cart", "", true, false)
+}
+
+func BenchmarkUiConfigHomeGzip(b *testing.B) {
+	benchmarkUiConfig(b, "/api/ui-config?screen=/", "gzip", false, true)
+}
+
+func BenchmarkUiConfigHomeGzipUncached(b *testing.B) {
+	benchmarkUiConfig(b, "/api/ui-config?screen=/", "gzip", false, false)
+}
+
+func BenchmarkUiConfigHomeBrotli(b *testing.B) {
+	benchmarkUiConfig(b, "/api/ui-config?screen=/", "br", false, true)
+}
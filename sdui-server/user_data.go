@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ==================== CART, FAVORITES & ORDERS ====================
+
+type CartItem struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+type Order struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	Items     []CartItem `json:"items"`
+	Total     float64    `json:"total"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// userDataStore keeps per-user carts, favorites and orders in memory,
+// keyed by user id (guest ids included)
+type userDataStore struct {
+	mu        sync.RWMutex
+	carts     map[string][]CartItem
+	favorites map[string][]string
+	orders    map[string][]Order
+}
+
+var userData = &userDataStore{
+	carts:     make(map[string][]CartItem),
+	favorites: make(map[string][]string),
+	orders:    make(map[string][]Order),
+}
+
+func (s *userDataStore) cart(userID string) []CartItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]CartItem(nil), s.carts[userID]...)
+}
+
+func (s *userDataStore) addToCart(userID, productID string, quantity int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.carts[userID] = addCartItem(s.carts[userID], productID, quantity)
+}
+
+func (s *userDataStore) removeFromCart(userID, productID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := s.carts[userID][:0]
+	for _, item := range s.carts[userID] {
+		if item.ProductID != productID {
+			items = append(items, item)
+		}
+	}
+	s.carts[userID] = items
+}
+
+func (s *userDataStore) favoritesOf(userID string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.favorites[userID]...)
+}
+
+func (s *userDataStore) addFavorite(userID, productID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.favorites[userID] = addFavoriteID(s.favorites[userID], productID)
+}
+
+func (s *userDataStore) removeFavorite(userID, productID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := s.favorites[userID][:0]
+	for _, id := range s.favorites[userID] {
+		if id != productID {
+			ids = append(ids, id)
+		}
+	}
+	s.favorites[userID] = ids
+}
+
+func (s *userDataStore) ordersOf(userID string) []Order {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Order(nil), s.orders[userID]...)
+}
+
+// allOrders returns every order across users, for co-purchase mining
+func (s *userDataStore) allOrders() []Order {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var all []Order
+	for _, orders := range s.orders {
+		all = append(all, orders...)
+	}
+	return all
+}
+
+// checkout turns the user's cart into an order, priced from the catalog
+func (s *userDataStore) checkout(userID string) (Order, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := s.carts[userID]
+	if len(items) == 0 {
+		return Order{}, false
+	}
+
+	total := 0.0
+	for _, item := range items {
+		total += getProductByID(item.ProductID).Price * float64(item.Quantity)
+	}
+	order := Order{
+		ID:        "order_" + randomID(8),
+		UserID:    userID,
+		Items:     items,
+		Total:     math.Round(total*100) / 100,
+		CreatedAt: time.Now(),
+	}
+	s.orders[userID] = append(s.orders[userID], order)
+	delete(s.carts, userID)
+	return order, true
+}
+
+func addCartItem(items []CartItem, productID string, quantity int) []CartItem {
+	for i := range items {
+		if items[i].ProductID == productID {
+			items[i].Quantity += quantity
+			return items
+		}
+	}
+	return append(items, CartItem{ProductID: productID, Quantity: quantity})
+}
+
+func addFavoriteID(ids []string, productID string) []string {
+	for _, id := range ids {
+		if id == productID {
+			return ids
+		}
+	}
+	return append(ids, productID)
+}
+
+// mergeUserData folds a guest's cart, favorites and orders into an account,
+// summing quantities for products already in the account's cart
+func mergeUserData(fromID, toID string) {
+	userData.mu.Lock()
+	defer userData.mu.Unlock()
+
+	for _, item := range userData.carts[fromID] {
+		userData.carts[toID] = addCartItem(userData.carts[toID], item.ProductID, item.Quantity)
+	}
+	for _, id := range userData.favorites[fromID] {
+		userData.favorites[toID] = addFavoriteID(userData.favorites[toID], id)
+	}
+	userData.orders[toID] = append(userData.orders[toID], userData.orders[fromID]...)
+	delete(userData.carts, fromID)
+	delete(userData.favorites, fromID)
+	delete(userData.orders, fromID)
+
+	segments.invalidate(fromID)
+	segments.invalidate(toID)
+}
+
+// ==================== HANDLERS ====================
+
+func handleCart(w http.ResponseWriter, r *http.Request) {
+	userID := currentUserID(r)
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var item CartItem
+		if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if item.ProductID == "" {
+			http.Error(w, "product_id is required", http.StatusBadRequest)
+			return
+		}
+		if item.Quantity <= 0 {
+			item.Quantity = 1
+		}
+		userData.addToCart(userID, item.ProductID, item.Quantity)
+	case http.MethodDelete:
+		userData.removeFromCart(userID, r.URL.Query().Get("product_id"))
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items": userData.cart(userID),
+	})
+}
+
+func handleFavorites(w http.ResponseWriter, r *http.Request) {
+	userID := currentUserID(r)
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var body struct {
+			ProductID string `json:"product_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if body.ProductID == "" {
+			http.Error(w, "product_id is required", http.StatusBadRequest)
+			return
+		}
+		userData.addFavorite(userID, body.ProductID)
+		segments.invalidate(userID)
+	case http.MethodDelete:
+		userData.removeFavorite(userID, r.URL.Query().Get("product_id"))
+		segments.invalidate(userID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"product_ids": userData.favoritesOf(userID),
+	})
+}
+
+// handleOrders lists the user's orders (GET) or checks out the cart (POST)
+func handleOrders(w http.ResponseWriter, r *http.Request) {
+	userID := currentUserID(r)
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"orders": userData.ordersOf(userID),
+		})
+	case http.MethodPost:
+		order, ok := userData.checkout(userID)
+		if !ok {
+			http.Error(w, "Cart is empty", http.StatusBadRequest)
+			return
+		}
+		segments.invalidate(userID)
+		for _, item := range order.Items {
+			inventory.adjust(item.ProductID, -item.Quantity)
+		}
+		analytics.Enqueue(AnalyticsEvent{
+			ID:         "evt_" + randomID(8),
+			Type:       "purchase",
+			Data:       map[string]interface{}{"order_id": order.ID, "total": order.Total},
+			ServerTime: time.Now().UTC(),
+			Mode:       getCurrentMode(),
+			UserID:     userID,
+			Screen:     "/cart",
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(order)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// ==================== RENDER CONTEXT ====================
+
+// RenderContext is the per-request state layouts are evaluated against
+type RenderContext struct {
+	Screen    string
+	Mode      string
+	UserID    string
+	LoggedIn  bool
+	CartCount int
+	Segments  []string
+	Now       time.Time
+	Flags     FlagContext
+	Locale    string
+	ProductID string // the product on /product screens
+
+	Accessibility AccessibilityPrefs
+}
+
+func newRenderContext(r *http.Request, screen, mode string) RenderContext {
+	ctx := RenderContext{
+		Screen: screen,
+		Mode:   mode,
+		UserID: currentUserID(r),
+		Now:    time.Now(),
+		Flags:  flagContextFromRequest(r, mode),
+		Locale: negotiateLocale(r),
+
+		Accessibility: accessibilityFromRequest(r),
+	}
+	if session := sessionFromContext(r.Context()); session != nil {
+		ctx.LoggedIn = !session.Guest
+	}
+	if ctx.UserID != "" {
+		for _, item := range userData.cart(ctx.UserID) {
+			ctx.CartCount += item.Quantity
+		}
+	}
+	if screen == "/product" {
+		ctx.ProductID = r.URL.Query().Get("id")
+	}
+	ctx.Segments = segments.forUser(ctx.UserID)
+	return ctx
+}
+
+// ==================== VISIBILITY RULES ====================
+
+// applyVisibility drops components whose "visible_when" (or shorthand
+// "flag") condition fails, recursing into children, and strips the
+// conditions from what's left so clients never see them.
+//
+// A visible_when object ANDs together any of:
+//
+//	"modes":          ["morning", "day"]
+//	"segments":       ["returning_buyer"]   (user is in at least one)
+//	"platforms":      ["ios", "android"]
+//	"logged_in":      true | false
+//	"cart_non_empty": true | false
+//	"time_window":    {"start": "06:00", "end": "09:00"}  (server local time)
+//	"flag":           "flag_key" | {"key": "flag_key", "value": "express"}
+//	"any":            [ {condition}, ... ]  (at least one holds)
+//	"not":            {condition}
+func applyVisibility(components []interface{}, ctx RenderContext) []interface{} {
+	kept := make([]interface{}, 0, len(components))
+	for _, c := range components {
+		component, ok := c.(map[string]interface{})
+		if !ok {
+			kept = append(kept, c)
+			continue
+		}
+
+		visible := true
+		if condition, ok := component["flag"]; ok {
+			visible = flagConditionHolds(condition, ctx.Flags)
+		}
+		if condition, ok := component["visible_when"].(map[string]interface{}); ok && visible {
+			holds, err := evaluateCondition(condition, ctx)
+			if err != nil {
+				log.Printf("⚠️  Hiding component %v: %v", component["id"], err)
+			}
+			visible = holds && err == nil
+		}
+		if !visible {
+			continue
+		}
+		delete(component, "flag")
+		delete(component, "visible_when")
+
+		if children, ok := component["children"].([]interface{}); ok {
+			component["children"] = applyVisibility(children, ctx)
+		}
+		kept = append(kept, component)
+	}
+	return kept
+}
+
+func evaluateCondition(condition map[string]interface{}, ctx RenderContext) (bool, error) {
+	for key, value := range condition {
+		holds, err := evaluateClause(key, value, ctx)
+		if err != nil || !holds {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func evaluateClause(key string, value interface{}, ctx RenderContext) (bool, error) {
+	switch key {
+	case "modes":
+		return matchesAny(value, []string{ctx.Mode})
+	case "segments":
+		return matchesAny(value, ctx.Segments)
+	case "platforms":
+		return matchesAny(value, []string{ctx.Flags.Platform})
+	case "logged_in":
+		want, ok := value.(bool)
+		if !ok {
+			return false, fmt.Errorf("logged_in must be a boolean")
+		}
+		return ctx.LoggedIn == want, nil
+	case "cart_non_empty":
+		want, ok := value.(bool)
+		if !ok {
+			return false, fmt.Errorf("cart_non_empty must be a boolean")
+		}
+		return (ctx.CartCount > 0) == want, nil
+	case "time_window":
+		return inTimeWindow(value, ctx.Now)
+	case "flag":
+		return flagConditionHolds(value, ctx.Flags), nil
+	case "any":
+		options, ok := value.([]interface{})
+		if !ok {
+			return false, fmt.Errorf("any must be a list of conditions")
+		}
+		for _, option := range options {
+			condition, ok := option.(map[string]interface{})
+			if !ok {
+				return false, fmt.Errorf("any must be a list of conditions")
+			}
+			holds, err := evaluateCondition(condition, ctx)
+			if err != nil {
+				return false, err
+			}
+			if holds {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "not":
+		condition, ok := value.(map[string]interface{})
+		if !ok {
+			return false, fmt.Errorf("not must be a condition")
+		}
+		holds, err := evaluateCondition(condition, ctx)
+		return !holds && err == nil, err
+	}
+	return false, fmt.Errorf("unknown visibility condition %q", key)
+}
+
+// matchesAny reports whether any of have appears in the wanted list
+func matchesAny(wanted interface{}, have []string) (bool, error) {
+	var list []string
+	switch w := wanted.(type) {
+	case []string:
+		list = w
+	case []interface{}:
+		for _, item := range w {
+			s, ok := item.(string)
+			if !ok {
+				return false, fmt.Errorf("condition lists must contain strings")
+			}
+			list = append(list, s)
+		}
+	default:
+		return false, fmt.Errorf("condition must be a list of strings")
+	}
+
+	for _, h := range have {
+		if slices.Contains(list, h) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// inTimeWindow checks "HH:MM" start/end bounds; windows may wrap midnight
+func inTimeWindow(value interface{}, now time.Time) (bool, error) {
+	window, ok := value.(map[string]interface{})
+	if !ok {
+		return false, fmt.Errorf("time_window must be an object")
+	}
+	start, err := parseClock(window["start"])
+	if err != nil {
+		return false, fmt.Errorf("time_window start: %w", err)
+	}
+	end, err := parseClock(window["end"])
+	if err != nil {
+		return false, fmt.Errorf("time_window end: %w", err)
+	}
+
+	minute := now.Hour()*60 + now.Minute()
+	if start <= end {
+		return minute >= start && minute < end, nil
+	}
+	return minute >= start || minute < end, nil
+}
+
+func parseClock(value interface{}) (int, error) {
+	s, ok := value.(string)
+	if !ok {
+		return 0, fmt.Errorf("expected \"HH:MM\"")
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
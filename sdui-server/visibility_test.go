@@ -0,0 +1,92 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEvaluateCondition(t *testing.T) {
+	ctx := RenderContext{
+		Mode:      "morning",
+		LoggedIn:  true,
+		CartCount: 2,
+		Segments:  []string{segmentReturningBuyer},
+		Now:       time.Date(2025, 6, 1, 7, 30, 0, 0, time.Local),
+		Flags:     FlagContext{UserID: "u1", Platform: "ios", Mode: "morning"},
+	}
+	window := func(start, end string) map[string]interface{} {
+		return map[string]interface{}{"start": start, "end": end}
+	}
+
+	tests := []struct {
+		name      string
+		condition map[string]interface{}
+		want      bool
+		wantErr   bool
+	}{
+		{"empty condition", map[string]interface{}{}, true, false},
+		{"mode listed", map[string]interface{}{"modes": []interface{}{"day", "morning"}}, true, false},
+		{"mode not listed", map[string]interface{}{"modes": []interface{}{"night"}}, false, false},
+		{"segment", map[string]interface{}{"segments": []interface{}{segmentLapsed, segmentReturningBuyer}}, true, false},
+		{"platform", map[string]interface{}{"platforms": []interface{}{"android"}}, false, false},
+		{"logged in", map[string]interface{}{"logged_in": true}, true, false},
+		{"cart empty wanted", map[string]interface{}{"cart_non_empty": false}, false, false},
+		{"clauses are ANDed", map[string]interface{}{"logged_in": true, "modes": []interface{}{"night"}}, false, false},
+		{"inside time window", map[string]interface{}{"time_window": window("06:00", "09:00")}, true, false},
+		{"window end is exclusive", map[string]interface{}{"time_window": window("05:00", "07:30")}, false, false},
+		{"window wrapping midnight", map[string]interface{}{"time_window": window("22:00", "08:00")}, true, false},
+		{"flag", map[string]interface{}{"flag": "testimonials"}, true, false},
+		{"any", map[string]interface{}{"any": []interface{}{
+			map[string]interface{}{"modes": []interface{}{"night"}},
+			map[string]interface{}{"logged_in": true},
+		}}, true, false},
+		{"any with none holding", map[string]interface{}{"any": []interface{}{
+			map[string]interface{}{"modes": []interface{}{"night"}},
+		}}, false, false},
+		{"not", map[string]interface{}{"not": map[string]interface{}{"logged_in": false}}, true, false},
+		{"unknown clause", map[string]interface{}{"weather": "sunny"}, false, true},
+		{"bad boolean", map[string]interface{}{"logged_in": "yes"}, false, true},
+		{"bad list", map[string]interface{}{"modes": "morning"}, false, true},
+		{"bad clock", map[string]interface{}{"time_window": window("6am", "9am")}, false, true},
+		{"error under not stays hidden", map[string]interface{}{"not": map[string]interface{}{"weather": "sunny"}}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := evaluateCondition(tt.condition, ctx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("holds = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyVisibility(t *testing.T) {
+	quietLogs(t)
+	ctx := RenderContext{Mode: "day", Flags: FlagContext{Platform: "web", Mode: "day"}}
+	components := []interface{}{
+		map[string]interface{}{"id": "always"},
+		map[string]interface{}{"id": "night-only", "visible_when": map[string]interface{}{"modes": []interface{}{"night"}}},
+		map[string]interface{}{"id": "flagged-off", "flag": "flash_promo_badges"},
+		map[string]interface{}{"id": "broken", "visible_when": map[string]interface{}{"weather": "sunny"}},
+		map[string]interface{}{"id": "row", "visible_when": map[string]interface{}{"modes": []interface{}{"day"}}, "children": []interface{}{
+			map[string]interface{}{"id": "child-hidden", "visible_when": map[string]interface{}{"logged_in": true}},
+			map[string]interface{}{"id": "child-shown"},
+		}},
+	}
+
+	kept := applyVisibility(components, ctx)
+	if got, want := componentIDs(map[string]interface{}{"components": kept}), []string{"always", "row"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("kept %v, want %v", got, want)
+	}
+	row := kept[1].(map[string]interface{})
+	if _, ok := row["visible_when"]; ok {
+		t.Error("visible_when left in the served component")
+	}
+	if got, want := componentIDs(map[string]interface{}{"components": row["children"]}), []string{"child-shown"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("children %v, want %v", got, want)
+	}
+}